@@ -2,6 +2,7 @@ package acl
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -33,6 +34,98 @@ func (a ACL) GetPrivilege(priv Privileges) bool {
 	return false
 }
 
+// sameItem reports whether a and b are entries for the same grantee and
+// grantor.  Entries for different pairs are different aclitems, so they
+// share no privileges.
+func (a ACL) sameItem(b ACL) bool {
+	return a.Role == b.Role && a.GrantedBy == b.GrantedBy
+}
+
+// Union returns a with the privileges and grant options of b added.  If b is
+// for another grantee or grantor, a is returned unchanged.
+func (a ACL) Union(b ACL) ACL {
+	if !a.sameItem(b) {
+		return a
+	}
+
+	a.Privileges = a.Privileges.Union(b.Privileges)
+	a.GrantOptions = a.GrantOptions.Union(b.GrantOptions)
+	return a
+}
+
+// Intersect returns a with only the privileges and grant options b holds
+// too.  If b is for another grantee or grantor, a is returned without
+// privileges.
+func (a ACL) Intersect(b ACL) ACL {
+	if !a.sameItem(b) {
+		a.Privileges, a.GrantOptions = NoPrivs, NoPrivs
+		return a
+	}
+
+	a.Privileges = a.Privileges.Intersect(b.Privileges)
+	a.GrantOptions = a.GrantOptions.Intersect(b.GrantOptions).Intersect(a.Privileges)
+	return a
+}
+
+// Without returns a with the privileges of b removed, along with their grant
+// options, as REVOKE removes them.  If b is for another grantee or grantor,
+// a is returned unchanged.
+func (a ACL) Without(b ACL) ACL {
+	if !a.sameItem(b) {
+		return a
+	}
+
+	a.Privileges = a.Privileges.Without(b.Privileges)
+	a.GrantOptions = a.GrantOptions.Without(b.GrantOptions).Intersect(a.Privileges)
+	return a
+}
+
+// Contains reports whether a holds every privilege and grant option of b and
+// is for the same grantee and grantor.
+func (a ACL) Contains(b ACL) bool {
+	return a.sameItem(b) && a.Privileges.Contains(b.Privileges) && a.GrantOptions.Contains(b.GrantOptions)
+}
+
+// IsSubsetOf reports whether b holds every privilege and grant option of a
+// and is for the same grantee and grantor.
+func (a ACL) IsSubsetOf(b ACL) bool {
+	return b.Contains(a)
+}
+
+// Validate reports every way in which a is not a valid aclitem: bits set in
+// Privileges or GrantOptions that are not privileges, grant options held
+// without their privilege, which String cannot represent, and a grantor set
+// without a grantee.
+//
+// An empty Role is PUBLIC, so an entry with a grantor and no grantee is a
+// valid grant to PUBLIC as far as the Role alone can tell.  PostgreSQL never
+// grants options to PUBLIC though, so an entry with an empty Role and grant
+// options is taken to have lost its grantee.  The typed constructors such as
+// NewTable reject the first two, and Parse cannot produce them.
+func (a ACL) Validate() error {
+	errs := a.rightsErrors()
+	if a.Role == "" && a.GrantOptions&allPrivileges != NoPrivs {
+		errs = append(errs, fmt.Errorf("missing grantee: PUBLIC cannot hold grant options for %s", a.GrantOptions&allPrivileges))
+	}
+
+	return errors.Join(errs...)
+}
+
+// rightsErrors reports undefined privilege bits and grant options held
+// without their privilege.
+func (a ACL) rightsErrors() []error {
+	var errs []error
+	if bad := (a.Privileges | a.GrantOptions) &^ allPrivileges; bad != NoPrivs {
+		errs = append(errs, fmt.Errorf("undefined privilege bits %#04x", uint16(bad)))
+	}
+
+	if orphan := a.GrantOptions &^ a.Privileges & allPrivileges; orphan != NoPrivs {
+		errs = append(errs, fmt.Errorf("grant options for %s without the privileges", orphan))
+	}
+
+	return errs
+}
+
 // Parse parses a PostgreSQL aclitem string and returns an ACL
 func Parse(aclStr string) (ACL, error) {
 	acl := ACL{}
@@ -152,6 +245,23 @@ func (a ACL) String() string {
 	return b.String()
 }
 
+// MarshalText implements encoding.TextMarshaler using the aclitem format
+// produced by String.
+func (a ACL) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using Parse.
+func (a *ACL) UnmarshalText(text []byte) error {
+	acl, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	*a = acl
+	return nil
+}
+
 // permString is a small helper function that emits the permission bitmask as a
 // string.
 func permString(perms, grantOptions Privileges) string {
@@ -259,12 +369,15 @@ func quoteRole(role string) string {
 	return pq.QuoteIdentifier(role)
 }
 
-// validRights checks to make sure a given acl's permissions and grant options
-// don't exceed the specified mask valid privileges.
-func validRights(acl ACL, validPrivs Privileges) bool {
-	if (acl.Privileges|validPrivs) == validPrivs &&
-		(acl.GrantOptions|validPrivs) == validPrivs {
-		return true
+// validRights checks that a given acl's permissions and grant options don't
+// exceed the valid privileges of the named object type and that every grant
+// option comes with its privilege.  The mask is formatted as a number so the
+// error reads as it did before Privileges had a String method.
+func validRights(acl ACL, validPrivs Privileges, kind string) error {
+	if (acl.Privileges|validPrivs) != validPrivs ||
+		(acl.GrantOptions|validPrivs) != validPrivs {
+		return fmt.Errorf("invalid flags set for %s (%+q), only %+q allowed", kind, permString(acl.Privileges, acl.GrantOptions), uint16(validPrivs))
 	}
-	return false
+
+	return errors.Join(acl.rightsErrors()...)
 }
@@ -1,6 +1,7 @@
 package acl_test
 
 import (
+	"strings"
 	"testing"
 
 	acl "github.com/sean-/postgresql-acl"
@@ -144,3 +145,181 @@ func TestGetChecks(t *testing.T) {
 		})
 	}
 }
+
+func TestACLSets(t *testing.T) {
+	parse := func(s string) acl.ACL {
+		a, err := acl.Parse(s)
+		if err != nil {
+			t.Fatalf("unable to parse %+q: %v", s, err)
+		}
+		return a
+	}
+
+	tests := []struct {
+		name      string
+		a, b      string
+		union     string
+		intersect string
+		without   string
+		contains  bool
+	}{
+		{
+			name:      "disjoint",
+			a:         "ro=r*/app",
+			b:         "ro=w/app",
+			union:     "ro=r*w/app",
+			intersect: "ro=/app",
+			without:   "ro=r*/app",
+		},
+		{
+			name:      "grant option kept consistent",
+			a:         "rw=a*r*w/app",
+			b:         "rw=a*r/app",
+			union:     "rw=a*r*w/app",
+			intersect: "rw=a*r/app",
+			without:   "rw=w/app",
+			contains:  true,
+		},
+		{
+			name:      "grant option missing",
+			a:         "rw=a*rw/app",
+			b:         "rw=a*r*/app",
+			union:     "rw=a*r*w/app",
+			intersect: "rw=a*r/app",
+			without:   "rw=w/app",
+		},
+		{
+			name:      "other grantee",
+			a:         "rw=a*r*w/app",
+			b:         "ro=a*r/app",
+			union:     "rw=a*r*w/app",
+			intersect: "rw=/app",
+			without:   "rw=a*r*w/app",
+		},
+		{
+			name:      "other grantor",
+			a:         "rw=a*r*w/app",
+			b:         "rw=a*r/postgres",
+			union:     "rw=a*r*w/app",
+			intersect: "rw=/app",
+			without:   "rw=a*r*w/app",
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			a, b := parse(test.a), parse(test.b)
+			if got := a.Union(b).String(); got != test.union {
+				t.Fatalf("want %+q got %+q", test.union, got)
+			}
+			if got := a.Intersect(b).String(); got != test.intersect {
+				t.Fatalf("want %+q got %+q", test.intersect, got)
+			}
+			if got := a.Without(b).String(); got != test.without {
+				t.Fatalf("want %+q got %+q", test.without, got)
+			}
+			if a.Contains(b) != test.contains || b.IsSubsetOf(a) != test.contains {
+				t.Fatalf("expected %t", test.contains)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		acl  acl.ACL
+		errs []string
+	}{
+		{
+			name: "valid",
+			acl:  acl.ACL{Role: "ro", GrantedBy: "app", Privileges: acl.Select | acl.Update, GrantOptions: acl.Select},
+		},
+		{
+			name: "PUBLIC",
+			acl:  acl.ACL{GrantedBy: "app", Privileges: acl.Select},
+		},
+		{
+			name: "undefined bits",
+			acl:  acl.ACL{Role: "ro", GrantedBy: "app", Privileges: acl.Select | 1<<15},
+			errs: []string{"undefined privilege bits 0x8000"},
+		},
+		{
+			name: "orphan grant option",
+			acl:  acl.ACL{Role: "ro", GrantedBy: "app", Privileges: acl.Update, GrantOptions: acl.Select | acl.Update},
+			errs: []string{"grant options for SELECT without the privileges"},
+		},
+		{
+			name: "no grantor",
+			acl:  acl.ACL{Role: "ro", Privileges: acl.Select},
+		},
+		{
+			name: "grantor without grantee",
+			acl:  acl.ACL{GrantedBy: "app", Privileges: acl.Select, GrantOptions: acl.Select},
+			errs: []string{"missing grantee: PUBLIC cannot hold grant options for SELECT"},
+		},
+		{
+			name: "every violation",
+			acl:  acl.ACL{Privileges: 1, GrantOptions: acl.Insert},
+			errs: []string{
+				"undefined privilege bits 0x0001",
+				"grant options for INSERT without the privileges",
+				"missing grantee: PUBLIC cannot hold grant options for INSERT",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			err := test.acl.Validate()
+			if test.errs == nil {
+				if err != nil {
+					t.Fatalf("unable to validate: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected failure")
+			}
+			if got, want := err.Error(), strings.Join(test.errs, "\n"); got != want {
+				t.Fatalf("want %+q got %+q", want, got)
+			}
+		})
+	}
+}
+
+func TestInvalidFlagsError(t *testing.T) {
+	_, err := acl.NewTable(acl.ACL{Role: "ro", Privileges: acl.Execute})
+	if err == nil {
+		t.Fatalf("expected failure")
+	}
+
+	if got, want := err.Error(), `invalid flags set for table ("X"), only '\u00fe' allowed`; got != want {
+		t.Fatalf("want %+q got %+q", want, got)
+	}
+}
+
+func TestOrphanGrantOptions(t *testing.T) {
+	if _, err := acl.NewTable(acl.ACL{Role: "ro", GrantOptions: acl.Select}); err == nil {
+		t.Fatalf("expected failure")
+	}
+
+	if _, err := acl.NewSchema(acl.ACL{Role: "ro", Privileges: 1 << 14}); err == nil {
+		t.Fatalf("expected failure")
+	}
+
+	for _, s := range []string{"ro=*r/app", "ro=r**/app"} {
+		if _, err := acl.Parse(s); err == nil {
+			t.Fatalf("expected failure for %+q", s)
+		}
+	}
+}
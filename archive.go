@@ -0,0 +1,368 @@
+package acl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Archive formats and versions of pg_dump archives, see
+// postgresql/src/bin/pg_dump/pg_backup_archiver.h.
+const (
+	archiveCustom    = 1
+	archiveDirectory = 5
+
+	archiveVersion1_11 = 1<<16 | 11<<8 // section numbers
+	archiveVersion1_14 = 1<<16 | 14<<8 // table access methods
+	archiveVersion1_15 = 1<<16 | 15<<8 // compression algorithm in header
+	archiveVersion1_16 = 1<<16 | 16<<8 // relkind
+)
+
+// Archive describes a pg_dump archive read with ReadArchive.
+type Archive struct {
+	// Database is the name of the dumped database.
+	Database string
+
+	// ServerVersion and DumpVersion are the versions of the server that was
+	// dumped and of pg_dump, e.g. "16.4".
+	ServerVersion string
+	DumpVersion   string
+
+	// Created is the time the dump was started on the clock of the machine
+	// that ran pg_dump.  Archives do not record its time zone, so Created is
+	// in UTC.
+	Created time.Time
+
+	*Dump
+}
+
+// tocEntry is an entry of the table of contents of a pg_dump archive.
+type tocEntry struct {
+	id       int
+	tag      string
+	desc     string
+	owner    string
+	defn     string
+	dropStmt string
+}
+
+// ReadArchive reads the table of contents of a custom-format archive written
+// by pg_dump -Fc, or of the toc.dat file of a directory-format archive, and
+// rebuilds ACL lists from its entries without pg_restore.
+//
+// ACL and DEFAULT ACL entries are replayed as ReadDump replays their
+// statements.  Custom archives contain no OWNER TO statements, so owners are
+// taken from the owner of every entry whose DROP statement names a securable
+// object.  Archives written by pg_dump from PostgreSQL 9.0 through 17 are
+// supported.
+//
+// Entries whose statements cannot be parsed are reported joined with
+// errors.Join, alongside the privileges that could be rebuilt.
+func ReadArchive(r io.Reader) (*Archive, error) {
+	ar := &archiveReader{r: bufio.NewReader(r)}
+	a, format, err := ar.header()
+	if err != nil {
+		return nil, err
+	}
+
+	d := newDumpState()
+	var errs []error
+	for n, i := ar.int(), 0; i < n && ar.err == nil; i++ {
+		te := ar.entry(format)
+		if ar.err != nil {
+			break
+		}
+
+		if err := d.entry(te); err != nil {
+			errs = append(errs, fmt.Errorf("TOC entry %d (%s %s): %w", te.id, te.desc, te.tag, err))
+		}
+	}
+
+	if ar.err != nil {
+		return nil, fmt.Errorf("unable to read archive TOC: %w", ar.err)
+	}
+
+	a.Dump = d.dump()
+	return a, errors.Join(errs...)
+}
+
+// entry replays one TOC entry.
+func (d *dumpState) entry(te tocEntry) error {
+	switch te.desc {
+	case "ACL", "DEFAULT ACL":
+		return d.aclEntry(te)
+	case "BLOB":
+		oid, err := strconv.ParseUint(te.tag, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid large object OID %+q", te.tag)
+		}
+		d.setOwner(Object{Kind: KindLargeObject, OID: uint32(oid)}, te.owner)
+	case "BLOB METADATA":
+		// Large objects are created in groups, with one lo_create() call
+		// each.
+		toks, err := lex(te.defn)
+		if err != nil {
+			return err
+		}
+		for i := 0; i+3 < len(toks); i++ {
+			if toks[i].kind == tokWord && toks[i].value == "lo_create" && toks[i+1].text == "(" && toks[i+2].kind == tokString {
+				oid, err := strconv.ParseUint(toks[i+2].text[1:len(toks[i+2].text)-1], 10, 32)
+				if err != nil {
+					return fmt.Errorf("invalid large object OID %s", toks[i+2])
+				}
+				d.setOwner(Object{Kind: KindLargeObject, OID: uint32(oid)}, te.owner)
+			}
+		}
+	default:
+		if te.owner == "" {
+			return nil
+		}
+
+		toks, err := lex(te.dropStmt)
+		if err != nil {
+			return nil
+		}
+
+		// DROP statements name the object with the same quoted, qualified
+		// name an OWNER TO would use.
+		p := &stmtParser{toks: toks}
+		if !p.words("drop") {
+			return nil
+		}
+		if obj, ok := p.dropped(); ok {
+			d.setOwner(obj, te.owner)
+		}
+	}
+
+	return nil
+}
+
+// dropped parses the remainder of a DROP statement of a securable object.
+func (p *stmtParser) dropped() (Object, bool) {
+	kind := ObjectKind("")
+	for _, k := range ownerKeywords {
+		if p.words(k.words...) {
+			kind = k.kind
+			break
+		}
+	}
+
+	if kind == "" {
+		return Object{}, false
+	}
+
+	obj, err := p.object(kind)
+	if err != nil {
+		return Object{}, false
+	}
+
+	p.punct(";")
+	if p.peek().kind != tokEOF {
+		return Object{}, false
+	}
+
+	return obj, true
+}
+
+// aclEntry replays the statements of an ACL or DEFAULT ACL entry.  The
+// entry's owner is the owner of the objects it grants on.
+func (d *dumpState) aclEntry(te tocEntry) error {
+	toks, err := lex(te.defn)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, stmt := range splitStatements(toks) {
+		if isACLStatement(stmt) && te.desc == "ACL" && te.owner != "" {
+			stmts, err := parseTokens(stmt)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			for _, s := range stmts {
+				for _, o := range s.Objects {
+					if obj := d.object(o); o.Kind != KindColumn && obj.Owner == "" {
+						obj.Owner = te.owner
+					}
+				}
+			}
+		}
+
+		if err := d.exec(stmt); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Each entry is restored in its own session.
+	d.session, d.role = "", ""
+
+	return errors.Join(errs...)
+}
+
+// archiveReader decodes the integers and strings of a pg_dump archive.  The
+// first error is kept in err and turns later reads into no-ops.
+type archiveReader struct {
+	r       *bufio.Reader
+	version int
+	intSize int
+	offSize int
+	err     error
+}
+
+// header reads the archive header and returns the archive format.
+func (ar *archiveReader) header() (*Archive, int, error) {
+	magic := make([]byte, 5)
+	if _, err := io.ReadFull(ar.r, magic); err != nil || string(magic) != "PGDMP" {
+		return nil, 0, errors.New("not a pg_dump archive")
+	}
+
+	major, minor, rev := ar.byte(), ar.byte(), ar.byte()
+	ar.version = int(major)<<16 | int(minor)<<8 | int(rev)
+	if ar.err == nil && (major != 1 || minor < 10 || minor > 16) {
+		return nil, 0, fmt.Errorf("unsupported archive version %d.%d.%d, only versions 1.10 through 1.16 are supported", major, minor, rev)
+	}
+
+	ar.intSize, ar.offSize = int(ar.byte()), int(ar.byte())
+	format := int(ar.byte())
+	if ar.err == nil && format != archiveCustom && format != archiveDirectory {
+		return nil, 0, fmt.Errorf("unsupported archive format %d, only custom and directory archives are supported", format)
+	}
+
+	if ar.version >= archiveVersion1_15 {
+		ar.byte() // compression algorithm
+	} else {
+		ar.int() // compression level
+	}
+
+	sec, minute, hour, mday, mon, year := ar.int(), ar.int(), ar.int(), ar.int(), ar.int(), ar.int()
+	ar.int() // isdst
+
+	a := &Archive{
+		Created:  time.Date(year+1900, time.Month(mon+1), mday, hour, minute, sec, 0, time.UTC),
+		Database: ar.str(),
+	}
+	a.ServerVersion, a.DumpVersion = ar.str(), ar.str()
+
+	if ar.err != nil {
+		return nil, 0, fmt.Errorf("unable to read archive header: %w", ar.err)
+	}
+
+	return a, format, nil
+}
+
+// entry reads one TOC entry.
+func (ar *archiveReader) entry(format int) tocEntry {
+	te := tocEntry{id: ar.int()}
+	ar.int() // had dumper
+	ar.str() // table OID
+	ar.str() // OID
+	te.tag = ar.str()
+	te.desc = ar.str()
+	if ar.version >= archiveVersion1_11 {
+		ar.int() // section
+	}
+	te.defn = ar.str()
+	te.dropStmt = ar.str()
+	ar.str() // copy statement
+	ar.str() // namespace
+	ar.str() // tablespace
+	if ar.version >= archiveVersion1_14 {
+		ar.str() // table access method
+	}
+	if ar.version >= archiveVersion1_16 {
+		ar.int() // relkind
+	}
+	te.owner = ar.str()
+	ar.str() // with OIDs
+
+	// Dependencies end with a NULL string.
+	for ar.err == nil {
+		if _, ok := ar.nullableStr(); !ok {
+			break
+		}
+	}
+
+	if format == archiveCustom {
+		ar.byte() // data offset flag
+		ar.skip(ar.offSize)
+	} else {
+		ar.str() // data file name
+	}
+
+	return te
+}
+
+func (ar *archiveReader) byte() byte {
+	if ar.err != nil {
+		return 0
+	}
+
+	b, err := ar.r.ReadByte()
+	if err != nil {
+		ar.err = noEOF(err)
+	}
+
+	return b
+}
+
+func (ar *archiveReader) skip(n int) {
+	if ar.err != nil {
+		return
+	}
+
+	if _, err := ar.r.Discard(n); err != nil {
+		ar.err = noEOF(err)
+	}
+}
+
+// int reads a sign byte followed by an intSize-byte little-endian magnitude.
+func (ar *archiveReader) int() int {
+	negative := ar.byte() != 0
+	var v int
+	for i := 0; i < ar.intSize; i++ {
+		v |= int(ar.byte()) << (8 * i)
+	}
+
+	if negative {
+		return -v
+	}
+
+	return v
+}
+
+// str reads a string, returning "" for NULL.
+func (ar *archiveReader) str() string {
+	s, _ := ar.nullableStr()
+	return s
+}
+
+// nullableStr reads a length-prefixed string.  ok is false for NULL, which
+// is written with a negative length.
+func (ar *archiveReader) nullableStr() (string, bool) {
+	n := ar.int()
+	if ar.err != nil || n < 0 {
+		return "", false
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(ar.r, b); err != nil {
+		ar.err = noEOF(err)
+		return "", false
+	}
+
+	return string(b), true
+}
+
+// noEOF reports a premature end of the archive as io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,159 @@
+package acl_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// archiveWriter writes the header and TOC of a pg_dump archive with 4-byte
+// integers and 8-byte offsets.
+type archiveWriter struct {
+	bytes.Buffer
+	minor byte
+}
+
+func (w *archiveWriter) int(v int) {
+	sign := byte(0)
+	if v < 0 {
+		sign, v = 1, -v
+	}
+	w.WriteByte(sign)
+	binary.Write(w, binary.LittleEndian, uint32(v))
+}
+
+func (w *archiveWriter) str(s string) {
+	w.int(len(s))
+	w.WriteString(s)
+}
+
+func (w *archiveWriter) header(format byte) {
+	w.WriteString("PGDMP")
+	w.Write([]byte{1, w.minor, 0, 4, 8, format})
+	if w.minor >= 15 {
+		w.WriteByte(0)
+	} else {
+		w.int(-1)
+	}
+	for _, v := range []int{5, 4, 3, 2, 0, 124, 0} {
+		w.int(v)
+	}
+	w.str("ledger")
+	w.str("16.4")
+	w.str("16.4")
+}
+
+func (w *archiveWriter) entry(id int, desc, tag, defn, drop, owner string) {
+	w.int(id)
+	w.int(0)
+	w.str("0")
+	w.str("0")
+	w.str(tag)
+	w.str(desc)
+	w.int(2)
+	w.str(defn)
+	w.str(drop)
+	w.str("")
+	w.str("app")
+	w.str("")
+	if w.minor >= 14 {
+		w.str("")
+	}
+	if w.minor >= 16 {
+		w.int(0)
+	}
+	w.str(owner)
+	w.str("false")
+	w.str("1")
+	w.int(-1)
+	w.Write(append([]byte{3}, make([]byte, 8)...))
+}
+
+func testArchive(minor byte) []byte {
+	w := &archiveWriter{minor: minor}
+	w.header(1)
+	w.int(9)
+	w.entry(1, "SCHEMA", "app", "CREATE SCHEMA app;\n", "DROP SCHEMA app;\n", "owner")
+	w.entry(2, "TABLE", "My Table", "CREATE TABLE app.\"My Table\" (id integer);\n", "DROP TABLE app.\"My Table\";\n", "owner")
+	w.entry(3, "FUNCTION", "touch(a integer)", "CREATE FUNCTION app.touch(a integer) RETURNS void AS $$ $$;\n", "DROP FUNCTION app.touch(a integer);\n", "owner")
+	w.entry(4, "INDEX", "my_idx", "CREATE INDEX my_idx ON app.\"My Table\" (id);\n", "DROP INDEX app.my_idx;\n", "owner")
+	w.entry(5, "BLOB", "16440", "SELECT pg_catalog.lo_create('16440');\n", "SELECT pg_catalog.lo_unlink('16440');\n", "owner")
+	w.entry(6, "ACL", "SCHEMA app", "GRANT USAGE ON SCHEMA app TO ro;\n", "", "owner")
+	w.entry(7, "ACL", "TABLE \"My Table\"", "GRANT SELECT ON TABLE app.\"My Table\" TO ro WITH GRANT OPTION;\nSET SESSION AUTHORIZATION ro;\nGRANT SELECT ON TABLE app.\"My Table\" TO audit;\nRESET SESSION AUTHORIZATION;\n", "", "owner")
+	w.entry(8, "ACL", "FUNCTION touch(a integer)", "REVOKE ALL ON FUNCTION app.touch(a integer) FROM PUBLIC;\n", "", "owner")
+	w.entry(9, "DEFAULT ACL", "DEFAULT PRIVILEGES FOR TABLES", "ALTER DEFAULT PRIVILEGES FOR ROLE owner IN SCHEMA app GRANT SELECT ON TABLES TO ro;\n", "", "owner")
+
+	return w.Bytes()
+}
+
+func TestReadArchive(t *testing.T) {
+	objects := []string{
+		`schema:"app" owner=UC/owner ro=U/owner`,
+		`table:"app"."My Table" owner=arwdDxt/owner ro=r*/owner audit=r/ro`,
+		`function:"app"."touch"(a integer) owner=X/owner`,
+		`large_object:16440 owner=rw/owner`,
+	}
+	defaults := []string{`default:table:role=owner:schema=app ro=r/owner`}
+
+	tests := []struct {
+		name    string
+		archive []byte
+		fail    string
+	}{
+		{name: "1.14", archive: testArchive(14)},
+		{name: "1.15", archive: testArchive(15)},
+		{name: "1.16", archive: testArchive(16)},
+		{name: "truncated", archive: testArchive(16)[:200], fail: "unexpected EOF"},
+		{name: "plain", archive: []byte("--\n-- PostgreSQL database dump\n"), fail: "not a pg_dump archive"},
+		{name: "version", archive: testArchive(9), fail: "unsupported archive version 1.9.0"},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			a, err := acl.ReadArchive(bytes.NewReader(test.archive))
+			if test.fail != "" {
+				if err == nil || !strings.Contains(err.Error(), test.fail) {
+					t.Fatalf("bad: expected %v to contain %+q", err, test.fail)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unable to read archive: %v", err)
+			}
+
+			if a.Database != "ledger" || a.ServerVersion != "16.4" {
+				t.Fatalf("bad: unexpected archive header %+v", a)
+			}
+
+			if want := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC); !a.Created.Equal(want) {
+				t.Fatalf("bad: expected %v to equal %v", a.Created, want)
+			}
+
+			var gotObjects []string
+			for _, o := range a.Objects {
+				gotObjects = append(gotObjects, dumpLine(o.Key(), o.ACL))
+			}
+			if !reflect.DeepEqual(gotObjects, objects) {
+				t.Fatalf("bad: expected %#v to equal %#v", gotObjects, objects)
+			}
+
+			var gotDefaults []string
+			for _, def := range a.DefaultACLs {
+				gotDefaults = append(gotDefaults, dumpLine(def.Key(), def.ACL))
+			}
+			if !reflect.DeepEqual(gotDefaults, defaults) {
+				t.Fatalf("bad: expected %#v to equal %#v", gotDefaults, defaults)
+			}
+		})
+	}
+}
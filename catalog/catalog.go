@@ -0,0 +1,216 @@
+// Package catalog loads securable objects and their ACLs from the PostgreSQL
+// system catalogs.
+//
+// Every loader accepts a Querier, which is satisfied by *sql.DB, *sql.Tx and
+// *sql.Conn, so introspection can run inside the same transaction as the
+// statements that change privileges.  ACLs that are NULL in the catalog are
+// expanded to the built-in defaults using acldefault() so the returned lists
+// always reflect the privileges in effect.
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// Querier is the subset of *sql.DB, *sql.Tx and *sql.Conn used by the loaders.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Catalog holds every object type that carries an ACL, along with the default
+// privileges and role memberships that determine access to them.
+type Catalog struct {
+	Schemas             []Schema
+	Relations           []Relation
+	Sequences           []Sequence
+	Columns             []Column
+	Functions           []Function
+	Types               []Type
+	Domains             []Domain
+	Languages           []Language
+	ForeignDataWrappers []ForeignDataWrapper
+	ForeignServers      []ForeignServer
+	Databases           []Database
+	Tablespaces         []Tablespace
+	LargeObjects        []LargeObject
+	DefaultACLs         []DefaultACL
+	RoleMemberships     []RoleMembership
+	InitialPrivileges   []InitialPrivilege
+}
+
+// Load reads every supported object type.  Schema-scoped objects are limited
+// to the named schemas, or to all non-system schemas if none are given.
+func Load(ctx context.Context, q Querier, schemas ...string) (*Catalog, error) {
+	c := &Catalog{}
+	var err error
+
+	if c.Schemas, err = LoadSchemas(ctx, q, schemas...); err != nil {
+		return nil, err
+	}
+	if c.Relations, err = LoadRelations(ctx, q, schemas...); err != nil {
+		return nil, err
+	}
+	if c.Sequences, err = LoadSequences(ctx, q, schemas...); err != nil {
+		return nil, err
+	}
+	if c.Columns, err = LoadColumns(ctx, q, schemas...); err != nil {
+		return nil, err
+	}
+	if c.Functions, err = LoadFunctions(ctx, q, schemas...); err != nil {
+		return nil, err
+	}
+	if c.Types, err = LoadTypes(ctx, q, schemas...); err != nil {
+		return nil, err
+	}
+	if c.Domains, err = LoadDomains(ctx, q, schemas...); err != nil {
+		return nil, err
+	}
+	if c.Languages, err = LoadLanguages(ctx, q); err != nil {
+		return nil, err
+	}
+	if c.ForeignDataWrappers, err = LoadForeignDataWrappers(ctx, q); err != nil {
+		return nil, err
+	}
+	if c.ForeignServers, err = LoadForeignServers(ctx, q); err != nil {
+		return nil, err
+	}
+	if c.Databases, err = LoadDatabases(ctx, q); err != nil {
+		return nil, err
+	}
+	if c.Tablespaces, err = LoadTablespaces(ctx, q); err != nil {
+		return nil, err
+	}
+	if c.LargeObjects, err = LoadLargeObjects(ctx, q); err != nil {
+		return nil, err
+	}
+	if c.DefaultACLs, err = LoadDefaultACLs(ctx, q); err != nil {
+		return nil, err
+	}
+	if c.RoleMemberships, err = LoadRoleMemberships(ctx, q); err != nil {
+		return nil, err
+	}
+	if c.InitialPrivileges, err = LoadInitialPrivileges(ctx, q); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Objects returns every loaded object as an acl.Object, ordered by kind in the
+// same order as the fields of Catalog.  Objects with a pg_init_privs entry
+// have their InitialACL set.
+func (c *Catalog) Objects() []acl.Object {
+	initial := indexInitialPrivileges(c.InitialPrivileges)
+
+	var objs []acl.Object
+	for _, o := range c.Schemas {
+		objs = append(objs, initial.apply(o.Object(), "pg_namespace", o.OID, 0))
+	}
+	for _, o := range c.Relations {
+		objs = append(objs, initial.apply(o.Object(), "pg_class", o.OID, 0))
+	}
+	for _, o := range c.Sequences {
+		objs = append(objs, initial.apply(o.Object(), "pg_class", o.OID, 0))
+	}
+	for _, o := range c.Columns {
+		objs = append(objs, initial.apply(o.Object(), "pg_class", o.RelationOID, o.Number))
+	}
+	for _, o := range c.Functions {
+		objs = append(objs, initial.apply(o.Object(), "pg_proc", o.OID, 0))
+	}
+	for _, o := range c.Types {
+		objs = append(objs, initial.apply(o.Object(), "pg_type", o.OID, 0))
+	}
+	for _, o := range c.Domains {
+		objs = append(objs, initial.apply(o.Object(), "pg_type", o.OID, 0))
+	}
+	for _, o := range c.Languages {
+		objs = append(objs, initial.apply(o.Object(), "pg_language", o.OID, 0))
+	}
+	for _, o := range c.ForeignDataWrappers {
+		objs = append(objs, initial.apply(o.Object(), "pg_foreign_data_wrapper", o.OID, 0))
+	}
+	for _, o := range c.ForeignServers {
+		objs = append(objs, initial.apply(o.Object(), "pg_foreign_server", o.OID, 0))
+	}
+	for _, o := range c.Databases {
+		objs = append(objs, o.Object())
+	}
+	for _, o := range c.Tablespaces {
+		objs = append(objs, o.Object())
+	}
+	for _, o := range c.LargeObjects {
+		objs = append(objs, initial.apply(o.Object(), "pg_largeobject", o.OID, 0))
+	}
+
+	return objs
+}
+
+// schemaFilter returns a predicate restricting the namespace column to the
+// given schemas, or excluding the system schemas if no schema was specified.
+// The predicate uses $1 when schemas are given and the returned args must be
+// passed to the query.
+func schemaFilter(column string, schemas []string) (string, []interface{}) {
+	if len(schemas) == 0 {
+		return fmt.Sprintf("%s !~ '^pg_' AND %s <> 'information_schema'", column, column), nil
+	}
+
+	return fmt.Sprintf("%s = ANY($1::TEXT[])", column), []interface{}{pq.Array(schemas)}
+}
+
+// queryRows runs query and calls scan once per returned row.
+func queryRows(ctx context.Context, q Querier, what, query string, scan func(*sql.Rows) error, args ...interface{}) error {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("unable to query %s: %w", what, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return fmt.Errorf("unable to load %s: %w", what, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("unable to load %s: %w", what, err)
+	}
+
+	return nil
+}
+
+// parseACLs parses each aclitem and validates it with the typed constructor
+// for the object.
+func parseACLs[T any](items []string, newFn func(acl.ACL) (T, error)) ([]T, error) {
+	acls := make([]T, 0, len(items))
+	for _, item := range items {
+		aclItem, err := acl.Parse(item)
+		if err != nil {
+			return nil, err
+		}
+
+		typed, err := newFn(aclItem)
+		if err != nil {
+			return nil, err
+		}
+
+		acls = append(acls, typed)
+	}
+
+	return acls, nil
+}
+
+// untyped strips the typed wrapper from each ACL.
+func untyped[T any](typed []T, get func(T) acl.ACL) []acl.ACL {
+	acls := make([]acl.ACL, len(typed))
+	for i, t := range typed {
+		acls[i] = get(t)
+	}
+
+	return acls
+}
@@ -0,0 +1,404 @@
+package catalog_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+	"github.com/sean-/postgresql-acl/catalog"
+	"github.com/sean-/postgresql-acl/internal/fakedb"
+)
+
+func catalogDB() *fakedb.DB {
+	return fakedb.New(
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_namespace n\n",
+			Rows: [][]driver.Value{
+				{int64(2200), "public", "postgres", "{postgres=UC/postgres,=U/postgres}"},
+			},
+		},
+		fakedb.Result{
+			Match: "c.relkind IN",
+			Rows: [][]driver.Value{
+				{int64(16384), "public", "accounts", "r", "app", "{app=arwdDxt/app,ro=r/app}"},
+			},
+		},
+		fakedb.Result{
+			Match: "c.relkind = 'S'",
+			Rows: [][]driver.Value{
+				{int64(16390), "public", "accounts_id_seq", "app", "{app=rwU/app}"},
+			},
+		},
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_attribute a",
+			Rows: [][]driver.Value{
+				{int64(16384), "public", "accounts", int64(2), "email", "app", "{ro=r/app}"},
+			},
+		},
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_proc p",
+			Rows: [][]driver.Value{
+				{int64(16400), "public", "balance", "account_id integer", false, "app", "{=X/app,app=X/app}"},
+				{int64(16401), "public", "close_month", "d date", true, "app", "{app=X/app,ops=X/app}"},
+			},
+		},
+		fakedb.Result{
+			Match: "t.typtype <> 'd'",
+			Rows: [][]driver.Value{
+				{int64(16410), "public", "mood", "app", "{=U/app,app=U/app}"},
+			},
+		},
+		fakedb.Result{
+			Match: "t.typtype = 'd'",
+			Rows: [][]driver.Value{
+				{int64(16420), "public", "email_address", "app", "{=U/app,app=U*/app}"},
+			},
+		},
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_language l",
+			Rows: [][]driver.Value{
+				{int64(13000), "plpgsql", "postgres", "{=U/postgres,postgres=U/postgres}"},
+			},
+		},
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_foreign_data_wrapper w",
+			Rows: [][]driver.Value{
+				{int64(16430), "postgres_fdw", "postgres", "{postgres=U/postgres}"},
+			},
+		},
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_foreign_server s",
+			Rows: [][]driver.Value{
+				{int64(16431), "remote", "postgres", "{postgres=U/postgres,app=U/postgres}"},
+			},
+		},
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_database d",
+			Rows: [][]driver.Value{
+				{int64(16385), "appdb", "postgres", "{=Tc/postgres,postgres=CTc/postgres}"},
+			},
+		},
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_tablespace t",
+			Rows: [][]driver.Value{
+				{int64(1663), "pg_default", "postgres", "{postgres=C/postgres}"},
+			},
+		},
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_largeobject_metadata m",
+			Rows: [][]driver.Value{
+				{int64(16440), "app", "{app=rw/app,ro=r/app}"},
+			},
+		},
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_default_acl d",
+			Rows: [][]driver.Value{
+				{"app", "public", "r", "{ro=r/app}"},
+				{"app", "", "f", "{ro=X/app}"},
+			},
+		},
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_init_privs p",
+			Rows: [][]driver.Value{
+				{"pg_proc", int64(16400), int64(0), true, "{=X/app,app=X/app}"},
+				{"pg_namespace", int64(2200), int64(0), false, "{postgres=UC/postgres,=U/postgres}"},
+			},
+		},
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_auth_members a",
+			Rows: [][]driver.Value{
+				{"readers", "ro", "postgres", true},
+			},
+		},
+	)
+}
+
+func mustParse(t *testing.T, aclStr string) acl.ACL {
+	t.Helper()
+
+	a, err := acl.Parse(aclStr)
+	if err != nil {
+		t.Fatalf("unable to parse ACLItem %+q: %v", aclStr, err)
+	}
+
+	return a
+}
+
+func TestLoad(t *testing.T) {
+	db := catalogDB().Open()
+	defer db.Close()
+
+	got, err := catalog.Load(context.Background(), db)
+	if err != nil {
+		t.Fatalf("unable to load catalog: %v", err)
+	}
+
+	want := &catalog.Catalog{
+		Schemas: []catalog.Schema{{
+			OID:   2200,
+			Name:  "public",
+			Owner: "postgres",
+			ACL: []acl.Schema{
+				{ACL: mustParse(t, "postgres=UC/postgres")},
+				{ACL: mustParse(t, "=U/postgres")},
+			},
+		}},
+		Relations: []catalog.Relation{{
+			OID:    16384,
+			Schema: "public",
+			Name:   "accounts",
+			Owner:  "app",
+			Kind:   acl.RelKindTable,
+			ACL: []acl.Table{
+				{ACL: mustParse(t, "app=arwdDxt/app")},
+				{ACL: mustParse(t, "ro=r/app")},
+			},
+		}},
+		Sequences: []catalog.Sequence{{
+			OID:    16390,
+			Schema: "public",
+			Name:   "accounts_id_seq",
+			Owner:  "app",
+			ACL:    []acl.Sequence{{ACL: mustParse(t, "app=rwU/app")}},
+		}},
+		Columns: []catalog.Column{{
+			RelationOID: 16384,
+			Schema:      "public",
+			Relation:    "accounts",
+			Number:      2,
+			Name:        "email",
+			Owner:       "app",
+			ACL:         []acl.Column{{ACL: mustParse(t, "ro=r/app")}},
+		}},
+		Functions: []catalog.Function{{
+			OID:       16400,
+			Schema:    "public",
+			Name:      "balance",
+			Signature: "account_id integer",
+			Owner:     "app",
+			ACL: []acl.Function{
+				{ACL: mustParse(t, "=X/app")},
+				{ACL: mustParse(t, "app=X/app")},
+			},
+		}, {
+			OID:       16401,
+			Schema:    "public",
+			Name:      "close_month",
+			Signature: "d date",
+			Procedure: true,
+			Owner:     "app",
+			ACL: []acl.Function{
+				{ACL: mustParse(t, "app=X/app")},
+				{ACL: mustParse(t, "ops=X/app")},
+			},
+		}},
+		Types: []catalog.Type{{
+			OID:    16410,
+			Schema: "public",
+			Name:   "mood",
+			Owner:  "app",
+			ACL: []acl.Type{
+				{ACL: mustParse(t, "=U/app")},
+				{ACL: mustParse(t, "app=U/app")},
+			},
+		}},
+		Domains: []catalog.Domain{{
+			OID:    16420,
+			Schema: "public",
+			Name:   "email_address",
+			Owner:  "app",
+			ACL: []acl.Domain{
+				{ACL: mustParse(t, "=U/app")},
+				{ACL: mustParse(t, "app=U*/app")},
+			},
+		}},
+		Languages: []catalog.Language{{
+			OID:   13000,
+			Name:  "plpgsql",
+			Owner: "postgres",
+			ACL: []acl.Language{
+				{ACL: mustParse(t, "=U/postgres")},
+				{ACL: mustParse(t, "postgres=U/postgres")},
+			},
+		}},
+		ForeignDataWrappers: []catalog.ForeignDataWrapper{{
+			OID:   16430,
+			Name:  "postgres_fdw",
+			Owner: "postgres",
+			ACL:   []acl.ForeignDataWrapper{{ACL: mustParse(t, "postgres=U/postgres")}},
+		}},
+		ForeignServers: []catalog.ForeignServer{{
+			OID:   16431,
+			Name:  "remote",
+			Owner: "postgres",
+			ACL: []acl.ForeignServer{
+				{ACL: mustParse(t, "postgres=U/postgres")},
+				{ACL: mustParse(t, "app=U/postgres")},
+			},
+		}},
+		Databases: []catalog.Database{{
+			OID:   16385,
+			Name:  "appdb",
+			Owner: "postgres",
+			ACL: []acl.Database{
+				{ACL: mustParse(t, "=Tc/postgres")},
+				{ACL: mustParse(t, "postgres=CTc/postgres")},
+			},
+		}},
+		Tablespaces: []catalog.Tablespace{{
+			OID:   1663,
+			Name:  "pg_default",
+			Owner: "postgres",
+			ACL:   []acl.Tablespace{{ACL: mustParse(t, "postgres=C/postgres")}},
+		}},
+		LargeObjects: []catalog.LargeObject{{
+			OID:   16440,
+			Owner: "app",
+			ACL: []acl.LargeObject{
+				{ACL: mustParse(t, "app=rw/app")},
+				{ACL: mustParse(t, "ro=r/app")},
+			},
+		}},
+		DefaultACLs: []catalog.DefaultACL{
+			{Role: "app", Schema: "public", Kind: acl.KindTable, ACL: []acl.ACL{mustParse(t, "ro=r/app")}},
+			{Role: "app", Kind: acl.KindFunction, ACL: []acl.ACL{mustParse(t, "ro=X/app")}},
+		},
+		RoleMemberships: []catalog.RoleMembership{
+			{Role: "readers", Member: "ro", Grantor: "postgres", AdminOption: true},
+		},
+		InitialPrivileges: []catalog.InitialPrivilege{{
+			Class:     "pg_proc",
+			OID:       16400,
+			Extension: true,
+			ACL:       []acl.ACL{mustParse(t, "=X/app"), mustParse(t, "app=X/app")},
+		}, {
+			Class: "pg_namespace",
+			OID:   2200,
+			ACL:   []acl.ACL{mustParse(t, "postgres=UC/postgres"), mustParse(t, "=U/postgres")},
+		}},
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("bad: expected %+v to equal %+v", want, got)
+	}
+}
+
+func TestSchemaFilter(t *testing.T) {
+	fake := catalogDB()
+	db := fake.Open()
+	defer db.Close()
+
+	if _, err := catalog.LoadRelations(context.Background(), db); err != nil {
+		t.Fatalf("unable to load relations: %v", err)
+	}
+
+	if args := fake.Args("c.relkind IN"); len(args) != 0 {
+		t.Fatalf("bad: expected no args, got %v", args)
+	}
+
+	if _, err := catalog.LoadRelations(context.Background(), db, "app", "billing"); err != nil {
+		t.Fatalf("unable to load relations: %v", err)
+	}
+
+	args := fake.Args("c.relkind IN")
+	if want := []driver.Value{`{"app","billing"}`}; !reflect.DeepEqual(want, args) {
+		t.Fatalf("bad: expected %v to equal %v", want, args)
+	}
+}
+
+func TestLoadInvalidACL(t *testing.T) {
+	tests := []struct {
+		name string
+		row  []driver.Value
+		err  string
+	}{
+		{
+			name: "bad aclitem",
+			row:  []driver.Value{int64(1), "public", "t", "r", "app", "{app%/app}"},
+			err:  "invalid aclStr format",
+		},
+		{
+			name: "unsupported relkind",
+			row:  []driver.Value{int64(1), "public", "t_pkey", "i", "app", "{}"},
+			err:  "does not carry privileges",
+		},
+		{
+			name: "sequence privilege on table",
+			row:  []driver.Value{int64(1), "public", "t", "r", "app", "{app=U/app}"},
+			err:  "invalid flags set for table",
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			db := fakedb.New(fakedb.Result{
+				Match: "c.relkind IN",
+				Rows:  [][]driver.Value{test.row},
+			}).Open()
+			defer db.Close()
+
+			_, err := catalog.LoadRelations(context.Background(), db)
+			if err == nil {
+				t.Fatalf("expected failure")
+			}
+
+			if !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("bad: expected %q to contain %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestObjects(t *testing.T) {
+	db := catalogDB().Open()
+	defer db.Close()
+
+	cat, err := catalog.Load(context.Background(), db)
+	if err != nil {
+		t.Fatalf("unable to load catalog: %v", err)
+	}
+
+	var keys, extension []string
+	for _, obj := range cat.Objects() {
+		if err := obj.Validate(); err != nil {
+			t.Fatalf("unable to validate %s: %v", obj.Key(), err)
+		}
+
+		keys = append(keys, obj.Key())
+		if obj.InitialACL != nil {
+			extension = append(extension, obj.Key())
+		}
+	}
+
+	want := []string{
+		`schema:"public"`,
+		`table:"public"."accounts"`,
+		`sequence:"public"."accounts_id_seq"`,
+		`column:"public"."accounts"."email"`,
+		`function:"public"."balance"(account_id integer)`,
+		`procedure:"public"."close_month"(d date)`,
+		`type:"public"."mood"`,
+		`domain:"public"."email_address"`,
+		`language:"plpgsql"`,
+		`foreign_data_wrapper:"postgres_fdw"`,
+		`foreign_server:"remote"`,
+		`database:"appdb"`,
+		`tablespace:"pg_default"`,
+		`large_object:16440`,
+	}
+
+	if !reflect.DeepEqual(want, keys) {
+		t.Fatalf("bad: expected %v to equal %v", want, keys)
+	}
+
+	if want := []string{`function:"public"."balance"(account_id integer)`}; !reflect.DeepEqual(want, extension) {
+		t.Fatalf("bad: expected %v to equal %v", want, extension)
+	}
+}
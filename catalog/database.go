@@ -0,0 +1,107 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// Database is a pg_database entry and its ACL.
+type Database struct {
+	OID   uint32
+	Name  string
+	Owner string
+	ACL   []acl.Database
+}
+
+// Tablespace is a pg_tablespace entry and its ACL.
+type Tablespace struct {
+	OID   uint32
+	Name  string
+	Owner string
+	ACL   []acl.Tablespace
+}
+
+// LoadDatabases returns every database in the cluster.
+func LoadDatabases(ctx context.Context, q Querier) ([]Database, error) {
+	const query = `SELECT d.oid, d.datname, pg_catalog.pg_get_userbyid(d.datdba),
+	COALESCE(d.datacl, pg_catalog.acldefault('d', d.datdba))::TEXT[]
+FROM pg_catalog.pg_database d
+ORDER BY d.datname`
+
+	var objs []Database
+	err := queryRows(ctx, q, "databases", query, func(rows *sql.Rows) error {
+		var obj Database
+		var acls []string
+		if err := rows.Scan(&obj.OID, &obj.Name, &obj.Owner, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		var err error
+		if obj.ACL, err = parseACLs(acls, acl.NewDatabase); err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// LoadTablespaces returns every tablespace in the cluster.
+func LoadTablespaces(ctx context.Context, q Querier) ([]Tablespace, error) {
+	const query = `SELECT t.oid, t.spcname, pg_catalog.pg_get_userbyid(t.spcowner),
+	COALESCE(t.spcacl, pg_catalog.acldefault('t', t.spcowner))::TEXT[]
+FROM pg_catalog.pg_tablespace t
+ORDER BY t.spcname`
+
+	var objs []Tablespace
+	err := queryRows(ctx, q, "tablespaces", query, func(rows *sql.Rows) error {
+		var obj Tablespace
+		var acls []string
+		if err := rows.Scan(&obj.OID, &obj.Name, &obj.Owner, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		var err error
+		if obj.ACL, err = parseACLs(acls, acl.NewTablespace); err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// Object returns the database as an acl.Object.
+func (d Database) Object() acl.Object {
+	return acl.Object{
+		Kind:  acl.KindDatabase,
+		Name:  d.Name,
+		OID:   d.OID,
+		Owner: d.Owner,
+		ACL:   untyped(d.ACL, func(a acl.Database) acl.ACL { return a.ACL }),
+	}
+}
+
+// Object returns the tablespace as an acl.Object.
+func (t Tablespace) Object() acl.Object {
+	return acl.Object{
+		Kind:  acl.KindTablespace,
+		Name:  t.Name,
+		OID:   t.OID,
+		Owner: t.Owner,
+		ACL:   untyped(t.ACL, func(a acl.Tablespace) acl.ACL { return a.ACL }),
+	}
+}
@@ -0,0 +1,65 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// DefaultACL is a pg_default_acl entry: the privileges applied to objects
+// created by Role, optionally only within Schema.
+type DefaultACL = acl.DefaultACL
+
+// defaultACLKinds maps pg_default_acl.defaclobjtype to an object kind.
+var defaultACLKinds = map[string]acl.ObjectKind{
+	"r": acl.KindTable,
+	"S": acl.KindSequence,
+	"f": acl.KindFunction,
+	"T": acl.KindType,
+	"n": acl.KindSchema,
+	"L": acl.KindLargeObject,
+}
+
+// LoadDefaultACLs returns every default privilege entry in the database.
+func LoadDefaultACLs(ctx context.Context, q Querier) ([]DefaultACL, error) {
+	const query = `SELECT pg_catalog.pg_get_userbyid(d.defaclrole), COALESCE(n.nspname, ''), d.defaclobjtype,
+	d.defaclacl::TEXT[]
+FROM pg_catalog.pg_default_acl d
+	LEFT JOIN pg_catalog.pg_namespace n ON n.oid = d.defaclnamespace
+ORDER BY 1, 2, 3`
+
+	var objs []DefaultACL
+	err := queryRows(ctx, q, "default ACLs", query, func(rows *sql.Rows) error {
+		var obj DefaultACL
+		var objtype string
+		var acls []string
+		if err := rows.Scan(&obj.Role, &obj.Schema, &objtype, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		kind, ok := defaultACLKinds[objtype]
+		if !ok {
+			return fmt.Errorf("unknown defaclobjtype %+q", objtype)
+		}
+		obj.Kind = kind
+
+		var err error
+		obj.ACL, err = parseACLs(acls, func(a acl.ACL) (acl.ACL, error) {
+			return a, kind.Validate(a)
+		})
+		if err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
@@ -0,0 +1,107 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// ForeignDataWrapper is a pg_foreign_data_wrapper entry and its ACL.
+type ForeignDataWrapper struct {
+	OID   uint32
+	Name  string
+	Owner string
+	ACL   []acl.ForeignDataWrapper
+}
+
+// ForeignServer is a pg_foreign_server entry and its ACL.
+type ForeignServer struct {
+	OID   uint32
+	Name  string
+	Owner string
+	ACL   []acl.ForeignServer
+}
+
+// LoadForeignDataWrappers returns every foreign-data wrapper.
+func LoadForeignDataWrappers(ctx context.Context, q Querier) ([]ForeignDataWrapper, error) {
+	const query = `SELECT w.oid, w.fdwname, pg_catalog.pg_get_userbyid(w.fdwowner),
+	COALESCE(w.fdwacl, pg_catalog.acldefault('F', w.fdwowner))::TEXT[]
+FROM pg_catalog.pg_foreign_data_wrapper w
+ORDER BY w.fdwname`
+
+	var objs []ForeignDataWrapper
+	err := queryRows(ctx, q, "foreign data wrappers", query, func(rows *sql.Rows) error {
+		var obj ForeignDataWrapper
+		var acls []string
+		if err := rows.Scan(&obj.OID, &obj.Name, &obj.Owner, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		var err error
+		if obj.ACL, err = parseACLs(acls, acl.NewForeignDataWrapper); err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// LoadForeignServers returns every foreign server.
+func LoadForeignServers(ctx context.Context, q Querier) ([]ForeignServer, error) {
+	const query = `SELECT s.oid, s.srvname, pg_catalog.pg_get_userbyid(s.srvowner),
+	COALESCE(s.srvacl, pg_catalog.acldefault('S', s.srvowner))::TEXT[]
+FROM pg_catalog.pg_foreign_server s
+ORDER BY s.srvname`
+
+	var objs []ForeignServer
+	err := queryRows(ctx, q, "foreign servers", query, func(rows *sql.Rows) error {
+		var obj ForeignServer
+		var acls []string
+		if err := rows.Scan(&obj.OID, &obj.Name, &obj.Owner, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		var err error
+		if obj.ACL, err = parseACLs(acls, acl.NewForeignServer); err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// Object returns the foreign data wrapper as an acl.Object.
+func (f ForeignDataWrapper) Object() acl.Object {
+	return acl.Object{
+		Kind:  acl.KindForeignDataWrapper,
+		Name:  f.Name,
+		OID:   f.OID,
+		Owner: f.Owner,
+		ACL:   untyped(f.ACL, func(a acl.ForeignDataWrapper) acl.ACL { return a.ACL }),
+	}
+}
+
+// Object returns the foreign server as an acl.Object.
+func (f ForeignServer) Object() acl.Object {
+	return acl.Object{
+		Kind:  acl.KindForeignServer,
+		Name:  f.Name,
+		OID:   f.OID,
+		Owner: f.Owner,
+		ACL:   untyped(f.ACL, func(a acl.ForeignServer) acl.ACL { return a.ACL }),
+	}
+}
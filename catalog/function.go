@@ -0,0 +1,79 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// Function is a pg_proc entry and its ACL.  Aggregates and window functions
+// are loaded as functions.
+type Function struct {
+	OID    uint32
+	Schema string
+	Name   string
+
+	// Procedure is true for procedures, whose privileges are granted ON
+	// PROCEDURE rather than ON FUNCTION.
+	Procedure bool
+
+	// Signature is the identity argument list of the function as returned by
+	// pg_get_function_identity_arguments(), e.g. "integer, text".
+	Signature string
+	Owner     string
+	ACL       []acl.Function
+}
+
+// LoadFunctions returns the functions and procedures in the given schemas.
+func LoadFunctions(ctx context.Context, q Querier, schemas ...string) ([]Function, error) {
+	filter, args := schemaFilter("n.nspname", schemas)
+	query := `SELECT p.oid, n.nspname, p.proname, pg_catalog.pg_get_function_identity_arguments(p.oid),
+	p.prokind = 'p', pg_catalog.pg_get_userbyid(p.proowner),
+	COALESCE(p.proacl, pg_catalog.acldefault('f', p.proowner))::TEXT[]
+FROM pg_catalog.pg_proc p
+	JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace
+WHERE ` + filter + `
+ORDER BY n.nspname, p.proname, 4`
+
+	var objs []Function
+	err := queryRows(ctx, q, "functions", query, func(rows *sql.Rows) error {
+		var obj Function
+		var acls []string
+		if err := rows.Scan(&obj.OID, &obj.Schema, &obj.Name, &obj.Signature, &obj.Procedure, &obj.Owner, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		var err error
+		if obj.ACL, err = parseACLs(acls, acl.NewFunction); err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// Object returns the function as an acl.Object.
+func (f Function) Object() acl.Object {
+	kind := acl.KindFunction
+	if f.Procedure {
+		kind = acl.KindProcedure
+	}
+
+	return acl.Object{
+		Kind:      kind,
+		Schema:    f.Schema,
+		Name:      f.Name,
+		Signature: f.Signature,
+		OID:       f.OID,
+		Owner:     f.Owner,
+		ACL:       untyped(f.ACL, func(a acl.Function) acl.ACL { return a.ACL }),
+	}
+}
@@ -0,0 +1,94 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// InitialPrivilege is a pg_init_privs entry: the ACL an object had when it
+// was created by an extension or by initdb.
+type InitialPrivilege struct {
+	// Class is the system catalog that holds the object, e.g. "pg_class" or
+	// "pg_proc".
+	Class string
+
+	OID uint32
+
+	// SubID is the column number for column privileges and zero otherwise.
+	SubID int16
+
+	// Extension is true for objects created by CREATE EXTENSION and false for
+	// those created by initdb.
+	Extension bool
+
+	ACL []acl.ACL
+}
+
+// LoadInitialPrivileges returns every pg_init_privs entry.
+func LoadInitialPrivileges(ctx context.Context, q Querier) ([]InitialPrivilege, error) {
+	const query = `SELECT p.classoid::pg_catalog.regclass::TEXT, p.objoid, p.objsubid, p.privtype = 'e',
+	p.initprivs::TEXT[]
+FROM pg_catalog.pg_init_privs p
+ORDER BY 1, 2, 3`
+
+	var objs []InitialPrivilege
+	err := queryRows(ctx, q, "initial privileges", query, func(rows *sql.Rows) error {
+		var obj InitialPrivilege
+		var acls []string
+		if err := rows.Scan(&obj.Class, &obj.OID, &obj.SubID, &obj.Extension, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		var err error
+		obj.ACL, err = parseACLs(acls, func(a acl.ACL) (acl.ACL, error) { return a, nil })
+		if err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// initialPrivileges indexes the pg_init_privs entries of extension objects by
+// catalog, OID and column number.
+type initialPrivileges map[initialPrivilegeKey][]acl.ACL
+
+type initialPrivilegeKey struct {
+	class string
+	oid   uint32
+	subID int16
+}
+
+// indexInitialPrivileges indexes the entries of extension objects only.  The
+// initdb entries of built-in objects are not an extension baseline, and the
+// grants users make on built-in objects must not be hidden as one.
+func indexInitialPrivileges(privs []InitialPrivilege) initialPrivileges {
+	idx := make(initialPrivileges, len(privs))
+	for _, p := range privs {
+		if !p.Extension {
+			continue
+		}
+		idx[initialPrivilegeKey{p.Class, p.OID, p.SubID}] = p.ACL
+	}
+
+	return idx
+}
+
+// apply sets the InitialACL of obj from the entry for the object with the
+// given catalog, OID and column number, if there is one.
+func (idx initialPrivileges) apply(obj acl.Object, class string, oid uint32, subID int16) acl.Object {
+	if initial, ok := idx[initialPrivilegeKey{class, oid, subID}]; ok {
+		obj.InitialACL = append([]acl.ACL{}, initial...)
+	}
+
+	return obj
+}
@@ -0,0 +1,58 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// Language is a pg_language entry and its ACL.
+type Language struct {
+	OID   uint32
+	Name  string
+	Owner string
+	ACL   []acl.Language
+}
+
+// LoadLanguages returns every procedural language.
+func LoadLanguages(ctx context.Context, q Querier) ([]Language, error) {
+	const query = `SELECT l.oid, l.lanname, pg_catalog.pg_get_userbyid(l.lanowner),
+	COALESCE(l.lanacl, pg_catalog.acldefault('l', l.lanowner))::TEXT[]
+FROM pg_catalog.pg_language l
+ORDER BY l.lanname`
+
+	var objs []Language
+	err := queryRows(ctx, q, "languages", query, func(rows *sql.Rows) error {
+		var obj Language
+		var acls []string
+		if err := rows.Scan(&obj.OID, &obj.Name, &obj.Owner, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		var err error
+		if obj.ACL, err = parseACLs(acls, acl.NewLanguage); err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// Object returns the language as an acl.Object.
+func (l Language) Object() acl.Object {
+	return acl.Object{
+		Kind:  acl.KindLanguage,
+		Name:  l.Name,
+		OID:   l.OID,
+		Owner: l.Owner,
+		ACL:   untyped(l.ACL, func(a acl.Language) acl.ACL { return a.ACL }),
+	}
+}
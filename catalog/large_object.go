@@ -0,0 +1,57 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// LargeObject is a pg_largeobject_metadata entry and its ACL.  Large objects
+// have no name and are identified by their OID alone.
+type LargeObject struct {
+	OID   uint32
+	Owner string
+	ACL   []acl.LargeObject
+}
+
+// LoadLargeObjects returns every large object in the database.
+func LoadLargeObjects(ctx context.Context, q Querier) ([]LargeObject, error) {
+	const query = `SELECT m.oid, pg_catalog.pg_get_userbyid(m.lomowner),
+	COALESCE(m.lomacl, pg_catalog.acldefault('L', m.lomowner))::TEXT[]
+FROM pg_catalog.pg_largeobject_metadata m
+ORDER BY m.oid`
+
+	var objs []LargeObject
+	err := queryRows(ctx, q, "large objects", query, func(rows *sql.Rows) error {
+		var obj LargeObject
+		var acls []string
+		if err := rows.Scan(&obj.OID, &obj.Owner, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		var err error
+		if obj.ACL, err = parseACLs(acls, acl.NewLargeObject); err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// Object returns the large object as an acl.Object.
+func (l LargeObject) Object() acl.Object {
+	return acl.Object{
+		Kind:  acl.KindLargeObject,
+		OID:   l.OID,
+		Owner: l.Owner,
+		ACL:   untyped(l.ACL, func(a acl.LargeObject) acl.ACL { return a.ACL }),
+	}
+}
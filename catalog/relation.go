@@ -0,0 +1,186 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// Relation is a pg_class entry that accepts table privileges: an ordinary
+// table, view, materialized view, foreign table or partitioned table.
+type Relation struct {
+	OID    uint32
+	Schema string
+	Name   string
+	Owner  string
+	Kind   acl.RelKind
+	ACL    []acl.Table
+}
+
+// Sequence is a pg_class entry with relkind 'S' and its ACL.
+type Sequence struct {
+	OID    uint32
+	Schema string
+	Name   string
+	Owner  string
+	ACL    []acl.Sequence
+}
+
+// Column is a pg_attribute entry that has column-level privileges.  Columns
+// without an attacl are omitted because they only inherit the privileges of
+// their relation.
+type Column struct {
+	RelationOID uint32
+	Schema      string
+	Relation    string
+	Number      int16
+	Name        string
+	Owner       string
+	ACL         []acl.Column
+}
+
+// LoadRelations returns the tables, views, materialized views, foreign tables
+// and partitioned tables in the given schemas.
+func LoadRelations(ctx context.Context, q Querier, schemas ...string) ([]Relation, error) {
+	filter, args := schemaFilter("n.nspname", schemas)
+	query := `SELECT c.oid, n.nspname, c.relname, c.relkind, pg_catalog.pg_get_userbyid(c.relowner),
+	COALESCE(c.relacl, pg_catalog.acldefault('r', c.relowner))::TEXT[]
+FROM pg_catalog.pg_class c
+	JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'v', 'm', 'f', 'p') AND ` + filter + `
+ORDER BY n.nspname, c.relname`
+
+	var objs []Relation
+	err := queryRows(ctx, q, "relations", query, func(rows *sql.Rows) error {
+		var obj Relation
+		var relkind string
+		var acls []string
+		if err := rows.Scan(&obj.OID, &obj.Schema, &obj.Name, &relkind, &obj.Owner, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		var err error
+		if obj.Kind, err = acl.ParseRelKind(relkind); err != nil {
+			return err
+		}
+
+		if obj.ACL, err = parseACLs(acls, acl.NewTable); err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// LoadSequences returns the sequences in the given schemas.
+func LoadSequences(ctx context.Context, q Querier, schemas ...string) ([]Sequence, error) {
+	filter, args := schemaFilter("n.nspname", schemas)
+	query := `SELECT c.oid, n.nspname, c.relname, pg_catalog.pg_get_userbyid(c.relowner),
+	COALESCE(c.relacl, pg_catalog.acldefault('s', c.relowner))::TEXT[]
+FROM pg_catalog.pg_class c
+	JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind = 'S' AND ` + filter + `
+ORDER BY n.nspname, c.relname`
+
+	var objs []Sequence
+	err := queryRows(ctx, q, "sequences", query, func(rows *sql.Rows) error {
+		var obj Sequence
+		var acls []string
+		if err := rows.Scan(&obj.OID, &obj.Schema, &obj.Name, &obj.Owner, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		var err error
+		if obj.ACL, err = parseACLs(acls, acl.NewSequence); err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// LoadColumns returns the columns in the given schemas that carry
+// column-level privileges.
+func LoadColumns(ctx context.Context, q Querier, schemas ...string) ([]Column, error) {
+	filter, args := schemaFilter("n.nspname", schemas)
+	query := `SELECT c.oid, n.nspname, c.relname, a.attnum, a.attname, pg_catalog.pg_get_userbyid(c.relowner),
+	a.attacl::TEXT[]
+FROM pg_catalog.pg_attribute a
+	JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+	JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE a.attacl IS NOT NULL AND a.attnum > 0 AND NOT a.attisdropped AND ` + filter + `
+ORDER BY n.nspname, c.relname, a.attnum`
+
+	var objs []Column
+	err := queryRows(ctx, q, "columns", query, func(rows *sql.Rows) error {
+		var obj Column
+		var acls []string
+		if err := rows.Scan(&obj.RelationOID, &obj.Schema, &obj.Relation, &obj.Number, &obj.Name, &obj.Owner, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		var err error
+		if obj.ACL, err = parseACLs(acls, acl.NewColumn); err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// Object returns the relation as an acl.Object.
+func (r Relation) Object() acl.Object {
+	return acl.Object{
+		Kind:   acl.KindTable,
+		Schema: r.Schema,
+		Name:   r.Name,
+		OID:    r.OID,
+		Owner:  r.Owner,
+		ACL:    untyped(r.ACL, func(a acl.Table) acl.ACL { return a.ACL }),
+	}
+}
+
+// Object returns the sequence as an acl.Object.
+func (s Sequence) Object() acl.Object {
+	return acl.Object{
+		Kind:   acl.KindSequence,
+		Schema: s.Schema,
+		Name:   s.Name,
+		OID:    s.OID,
+		Owner:  s.Owner,
+		ACL:    untyped(s.ACL, func(a acl.Sequence) acl.ACL { return a.ACL }),
+	}
+}
+
+// Object returns the column as an acl.Object.
+func (c Column) Object() acl.Object {
+	return acl.Object{
+		Kind:   acl.KindColumn,
+		Schema: c.Schema,
+		Name:   c.Relation,
+		Column: c.Name,
+		OID:    c.RelationOID,
+		Owner:  c.Owner,
+		ACL:    untyped(c.ACL, func(a acl.Column) acl.ACL { return a.ACL }),
+	}
+}
@@ -0,0 +1,39 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+)
+
+// RoleMembership is a pg_auth_members entry: Member is a member of Role.
+type RoleMembership struct {
+	Role        string
+	Member      string
+	Grantor     string
+	AdminOption bool
+}
+
+// LoadRoleMemberships returns every role membership in the cluster.
+func LoadRoleMemberships(ctx context.Context, q Querier) ([]RoleMembership, error) {
+	const query = `SELECT r.rolname, m.rolname, pg_catalog.pg_get_userbyid(a.grantor), a.admin_option
+FROM pg_catalog.pg_auth_members a
+	JOIN pg_catalog.pg_roles r ON r.oid = a.roleid
+	JOIN pg_catalog.pg_roles m ON m.oid = a.member
+ORDER BY 1, 2, 3`
+
+	var objs []RoleMembership
+	err := queryRows(ctx, q, "role memberships", query, func(rows *sql.Rows) error {
+		var obj RoleMembership
+		if err := rows.Scan(&obj.Role, &obj.Member, &obj.Grantor, &obj.AdminOption); err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
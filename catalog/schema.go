@@ -0,0 +1,61 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// Schema is a pg_namespace entry and its ACL.
+type Schema struct {
+	OID   uint32
+	Name  string
+	Owner string
+	ACL   []acl.Schema
+}
+
+// LoadSchemas returns the named schemas, or every non-system schema if no
+// schema is specified.
+func LoadSchemas(ctx context.Context, q Querier, schemas ...string) ([]Schema, error) {
+	filter, args := schemaFilter("n.nspname", schemas)
+	query := `SELECT n.oid, n.nspname, pg_catalog.pg_get_userbyid(n.nspowner),
+	COALESCE(n.nspacl, pg_catalog.acldefault('n', n.nspowner))::TEXT[]
+FROM pg_catalog.pg_namespace n
+WHERE ` + filter + `
+ORDER BY n.nspname`
+
+	var objs []Schema
+	err := queryRows(ctx, q, "schemas", query, func(rows *sql.Rows) error {
+		var obj Schema
+		var acls []string
+		if err := rows.Scan(&obj.OID, &obj.Name, &obj.Owner, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		var err error
+		if obj.ACL, err = parseACLs(acls, acl.NewSchema); err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// Object returns the schema as an acl.Object.
+func (s Schema) Object() acl.Object {
+	return acl.Object{
+		Kind:  acl.KindSchema,
+		Name:  s.Name,
+		OID:   s.OID,
+		Owner: s.Owner,
+		ACL:   untyped(s.ACL, func(a acl.Schema) acl.ACL { return a.ACL }),
+	}
+}
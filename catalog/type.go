@@ -0,0 +1,125 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// Type is a user-visible pg_type entry that is not a domain.  Array types and
+// the implicit row types of tables are omitted because their privileges
+// follow the element type and relation, respectively.
+type Type struct {
+	OID    uint32
+	Schema string
+	Name   string
+	Owner  string
+	ACL    []acl.Type
+}
+
+// Domain is a pg_type entry with typtype 'd' and its ACL.
+type Domain struct {
+	OID    uint32
+	Schema string
+	Name   string
+	Owner  string
+	ACL    []acl.Domain
+}
+
+// typeQuery returns the query used for both types and domains.  typtype is
+// the predicate on t.typtype that separates the two.
+func typeQuery(typtype string, schemas []string) (string, []interface{}) {
+	filter, args := schemaFilter("n.nspname", schemas)
+	query := `SELECT t.oid, n.nspname, t.typname, pg_catalog.pg_get_userbyid(t.typowner),
+	COALESCE(t.typacl, pg_catalog.acldefault('T', t.typowner))::TEXT[]
+FROM pg_catalog.pg_type t
+	JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace
+WHERE t.typtype ` + typtype + `
+	AND (t.typrelid = 0 OR (SELECT c.relkind = 'c' FROM pg_catalog.pg_class c WHERE c.oid = t.typrelid))
+	AND NOT EXISTS (SELECT 1 FROM pg_catalog.pg_type el WHERE el.oid = t.typelem AND el.typarray = t.oid)
+	AND ` + filter + `
+ORDER BY n.nspname, t.typname`
+
+	return query, args
+}
+
+// LoadTypes returns the base, composite, enum, pseudo and range types in the
+// given schemas.
+func LoadTypes(ctx context.Context, q Querier, schemas ...string) ([]Type, error) {
+	query, args := typeQuery("<> 'd'", schemas)
+
+	var objs []Type
+	err := queryRows(ctx, q, "types", query, func(rows *sql.Rows) error {
+		var obj Type
+		var acls []string
+		if err := rows.Scan(&obj.OID, &obj.Schema, &obj.Name, &obj.Owner, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		var err error
+		if obj.ACL, err = parseACLs(acls, acl.NewType); err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// LoadDomains returns the domains in the given schemas.
+func LoadDomains(ctx context.Context, q Querier, schemas ...string) ([]Domain, error) {
+	query, args := typeQuery("= 'd'", schemas)
+
+	var objs []Domain
+	err := queryRows(ctx, q, "domains", query, func(rows *sql.Rows) error {
+		var obj Domain
+		var acls []string
+		if err := rows.Scan(&obj.OID, &obj.Schema, &obj.Name, &obj.Owner, pq.Array(&acls)); err != nil {
+			return err
+		}
+
+		var err error
+		if obj.ACL, err = parseACLs(acls, acl.NewDomain); err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// Object returns the type as an acl.Object.
+func (t Type) Object() acl.Object {
+	return acl.Object{
+		Kind:   acl.KindType,
+		Schema: t.Schema,
+		Name:   t.Name,
+		OID:    t.OID,
+		Owner:  t.Owner,
+		ACL:    untyped(t.ACL, func(a acl.Type) acl.ACL { return a.ACL }),
+	}
+}
+
+// Object returns the domain as an acl.Object.
+func (d Domain) Object() acl.Object {
+	return acl.Object{
+		Kind:   acl.KindDomain,
+		Schema: d.Schema,
+		Name:   d.Name,
+		OID:    d.OID,
+		Owner:  d.Owner,
+		ACL:    untyped(d.ACL, func(a acl.Domain) acl.ACL { return a.ACL }),
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sean-/postgresql-acl/executor"
+	"github.com/sean-/postgresql-acl/plan"
+)
+
+func runApply(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var flags planFlags
+	flags.register(fs)
+	dryRun := fs.Bool("dry-run", false, "print the plan without applying it")
+	yes := fs.Bool("yes", false, "apply without asking for confirmation")
+	lockTimeout := fs.Duration("lock-timeout", 0, "give up on a statement that waits this long for a lock, e.g. 5s")
+	retries := fs.Int("retries", 3, "retry a statement that times out waiting for a lock this many times")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: pgacl apply [-dsn dsn] [-schema a,b] [-dry-run] [-yes] policy.yaml")
+		fmt.Fprintln(stderr, "       pgacl apply [-dsn dsn] [-dry-run] plan.json")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Prints the plan for the policy, asks for confirmation and runs the")
+		fmt.Fprintln(stderr, "statements in a single transaction, then checks that the database has")
+		fmt.Fprintln(stderr, "converged on the policy.  A plan saved by pgacl plan -out is run without")
+		fmt.Fprintln(stderr, "confirmation, but only if the ACLs in the database are unchanged since")
+		fmt.Fprintln(stderr, "it was made.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	saved, isPlan, err := readPlan(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "pgacl: %v\n", err)
+		return 1
+	}
+
+	if isPlan && flags.schemas != "" {
+		fmt.Fprintln(stderr, "pgacl: -schema cannot be used with a saved plan")
+		return 2
+	}
+
+	db, err := openDB(flags.dsn)
+	if err != nil {
+		fmt.Fprintf(stderr, "pgacl: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	e := &executor.Executor{
+		DB:          db,
+		DryRun:      *dryRun,
+		Savepoints:  true,
+		LockTimeout: *lockTimeout,
+		Retries:     *retries,
+		RetryDelay:  time.Second,
+		Log:         io.Discard,
+	}
+
+	ctx := context.Background()
+	if isPlan {
+		return applyPlan(ctx, e, saved, stdout, stderr)
+	}
+
+	pl, err := computePlan(ctx, db, fs.Arg(0), flags)
+	if err != nil {
+		fmt.Fprintf(stderr, "pgacl: %v\n", err)
+		return 1
+	}
+
+	printPlan(stdout, pl.stmts)
+	if len(pl.stmts) == 0 || *dryRun {
+		return 0
+	}
+
+	if !*yes {
+		fmt.Fprintf(stderr, "Apply %d statements? [y/N] ", len(pl.stmts))
+		answer, _ := bufio.NewReader(stdin).ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+		default:
+			fmt.Fprintln(stderr, "pgacl: aborted")
+			return 1
+		}
+	}
+
+	e.Verify = executor.Converged(pl.desired, pl.roles, flags.schemaList()...)
+	if err := e.Run(ctx, pl.stmts); err != nil {
+		fmt.Fprintf(stderr, "pgacl: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stderr, "applied %d statements\n", len(pl.stmts))
+	return 0
+}
+
+// readPlan returns the saved plan in filename, or false if the file is not a
+// plan, in which case it is treated as a policy.  A file that looks like a
+// plan is not treated as a policy when it cannot be read, so the error says
+// what is wrong with the plan.
+func readPlan(filename string) (*plan.File, bool, error) {
+	src, err := os.ReadFile(filename)
+	if err != nil || !looksLikePlan(src) {
+		return nil, false, nil
+	}
+
+	saved, err := plan.ReadFile(bytes.NewReader(src))
+	if err != nil {
+		return nil, true, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return saved, true, nil
+}
+
+// looksLikePlan returns true if src is a JSON object with a "state_hash" or
+// "statements" key, which policies do not have.  The object may be truncated
+// after the key.
+func looksLikePlan(src []byte) bool {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return false
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return false
+		}
+
+		if key == "state_hash" || key == "statements" {
+			return true
+		}
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return false
+		}
+	}
+
+	return false
+}
+
+// applyPlan runs a saved plan after checking, in the same transaction, that
+// the state it was computed from is unchanged, and then checks that the
+// database has converged on the plan's desired state.  In a dry run only the
+// first check is made.
+func applyPlan(ctx context.Context, e *executor.Executor, saved *plan.File, stdout, stderr io.Writer) int {
+	printPlan(stdout, saved.Statements)
+
+	e.Before = func(ctx context.Context, tx *sql.Tx) error {
+		actual, err := loadState(ctx, tx, saved.Schemas)
+		if err != nil {
+			return err
+		}
+
+		if err := saved.Check(actual); err != nil {
+			return fmt.Errorf("%w; run pgacl plan again", err)
+		}
+
+		return nil
+	}
+
+	e.Verify = executor.Converged(saved.Desired, saved.Roles, saved.Schemas...)
+	if err := e.Run(ctx, saved.Statements); err != nil {
+		fmt.Fprintf(stderr, "pgacl: %v\n", err)
+		return 1
+	}
+
+	if !e.DryRun {
+		fmt.Fprintf(stderr, "applied %d statements\n", len(saved.Statements))
+	}
+
+	return 0
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lib/pq"
+	acl "github.com/sean-/postgresql-acl"
+)
+
+func runExplain(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	kindName := fs.String("type", "", "validate each aclitem against an object type, e.g. table, sequence, schema")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: pgacl explain [-type kind] [aclitem | aclitem[] ...]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Explains each aclitem given as an argument, or read one per line from")
+		fmt.Fprintln(stderr, "stdin if there are no arguments.  Whole aclitem[] literals such as")
+		fmt.Fprintln(stderr, `{app=arwdDxt/postgres,=r/postgres} are accepted as well.`)
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var kind acl.ObjectKind
+	if *kindName != "" {
+		var err error
+		if kind, err = acl.ParseObjectKind(*kindName); err != nil {
+			fmt.Fprintf(stderr, "pgacl: %v\n", err)
+			return 2
+		}
+	}
+
+	inputs := fs.Args()
+	if len(inputs) == 0 {
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				inputs = append(inputs, line)
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			fmt.Fprintf(stderr, "pgacl: unable to read stdin: %v\n", err)
+			return 1
+		}
+	}
+
+	status := 0
+	first := true
+	for _, input := range inputs {
+		items, err := splitACLItems(input)
+		if err != nil {
+			fmt.Fprintf(stderr, "pgacl: %v\n", err)
+			status = 1
+			continue
+		}
+
+		for _, item := range items {
+			a, err := acl.Parse(item)
+			if err != nil {
+				fmt.Fprintf(stderr, "pgacl: %v\n", err)
+				status = 1
+				continue
+			}
+
+			if !first {
+				fmt.Fprintln(stdout)
+			}
+			first = false
+
+			explain(stdout, a)
+
+			if kind != "" {
+				if err := kind.Validate(a); err != nil {
+					fmt.Fprintf(stdout, "  invalid for %s: %v\n", kind, err)
+					status = 1
+				} else {
+					fmt.Fprintf(stdout, "  valid for %s\n", kind)
+				}
+			}
+		}
+	}
+
+	return status
+}
+
+// splitACLItems returns the aclitems in an aclitem[] literal, or the input
+// itself if it is a single aclitem.
+func splitACLItems(input string) ([]string, error) {
+	if !strings.HasPrefix(input, "{") {
+		return []string{input}, nil
+	}
+
+	var items pq.StringArray
+	if err := items.Scan([]byte(input)); err != nil {
+		return nil, fmt.Errorf("invalid aclitem[] literal %+q: %w", input, err)
+	}
+
+	return items, nil
+}
+
+// explain writes a description of a to w.
+func explain(w io.Writer, a acl.ACL) {
+	fmt.Fprintln(w, a.String())
+	fmt.Fprintf(w, "  grantee: %s\n", roleName(a.Role))
+	if a.GrantedBy != "" {
+		fmt.Fprintf(w, "  grantor: %s\n", a.GrantedBy)
+	}
+
+	if a.Privileges == acl.NoPrivs {
+		fmt.Fprintln(w, "  privileges: none")
+		return
+	}
+
+	fmt.Fprintln(w, "  privileges:")
+	for _, name := range a.Privileges.Names() {
+		priv, _ := acl.ParsePrivilege(name)
+		if a.GetGrantOption(priv) {
+			fmt.Fprintf(w, "    %s WITH GRANT OPTION\n", name)
+		} else {
+			fmt.Fprintf(w, "    %s\n", name)
+		}
+	}
+}
+
+// roleName returns role, or PUBLIC for the empty role.
+func roleName(role string) string {
+	if role == "" {
+		return "PUBLIC"
+	}
+
+	return role
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		status int
+		stdout string
+		stderr string
+	}{
+		{
+			name: "single aclitem",
+			args: []string{"app=r*w/postgres"},
+			stdout: `app=r*w/postgres
+  grantee: app
+  grantor: postgres
+  privileges:
+    SELECT WITH GRANT OPTION
+    UPDATE
+`,
+		},
+		{
+			name: "array literal",
+			args: []string{`{=U/postgres,"app=arwdDxt/postgres"}`},
+			stdout: `=U/postgres
+  grantee: PUBLIC
+  grantor: postgres
+  privileges:
+    USAGE
+
+app=arwdDxt/postgres
+  grantee: app
+  grantor: postgres
+  privileges:
+    DELETE
+    INSERT
+    REFERENCES
+    SELECT
+    TRIGGER
+    TRUNCATE
+    UPDATE
+`,
+		},
+		{
+			name:  "stdin",
+			stdin: "\nfoo=\n{bar=c/baz}\n",
+			stdout: `foo=
+  grantee: foo
+  privileges: none
+
+bar=c/baz
+  grantee: bar
+  grantor: baz
+  privileges:
+    CONNECT
+`,
+		},
+		{
+			name: "valid for type",
+			args: []string{"-type", "sequence", "app=rU/postgres"},
+			stdout: `app=rU/postgres
+  grantee: app
+  grantor: postgres
+  privileges:
+    SELECT
+    USAGE
+  valid for sequence
+`,
+		},
+		{
+			name:   "invalid for type",
+			args:   []string{"-type", "sequence", "app=arU/postgres"},
+			status: 1,
+			stdout: "  invalid for sequence: invalid flags set for sequence",
+		},
+		{
+			name:   "unknown type",
+			args:   []string{"-type", "widget", "app=r/postgres"},
+			status: 2,
+			stderr: `unknown object kind "widget"`,
+		},
+		{
+			name:   "bad aclitem",
+			args:   []string{"app=q/postgres", "app=r/postgres"},
+			status: 1,
+			stdout: "app=r/postgres\n",
+			stderr: "invalid byte q in aclitem",
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+			args := append([]string{"explain"}, test.args...)
+			if status := run(args, strings.NewReader(test.stdin), stdout, stderr); status != test.status {
+				t.Fatalf("bad: expected status %d, got %d: %s", test.status, status, stderr)
+			}
+
+			if !strings.Contains(stdout.String(), test.stdout) {
+				t.Fatalf("bad: expected %q to contain %q", stdout, test.stdout)
+			}
+
+			if !strings.Contains(stderr.String(), test.stderr) {
+				t.Fatalf("bad: expected %q to contain %q", stderr, test.stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		status int
+		stdout string
+		stderr string
+	}{
+		{
+			name:   "no command",
+			status: 2,
+			stderr: "usage: pgacl",
+		},
+		{
+			name:   "help",
+			args:   []string{"help"},
+			stdout: "explain",
+		},
+		{
+			name:   "unknown command",
+			args:   []string{"frobnicate"},
+			status: 2,
+			stderr: `unknown command "frobnicate"`,
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+			if status := run(test.args, strings.NewReader(""), stdout, stderr); status != test.status {
+				t.Fatalf("bad: expected status %d, got %d", test.status, status)
+			}
+
+			if !strings.Contains(stdout.String(), test.stdout) {
+				t.Fatalf("bad: expected %q to contain %q", stdout, test.stdout)
+			}
+
+			if !strings.Contains(stderr.String(), test.stderr) {
+				t.Fatalf("bad: expected %q to contain %q", stderr, test.stderr)
+			}
+		})
+	}
+}
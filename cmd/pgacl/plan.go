@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	acl "github.com/sean-/postgresql-acl"
+	"github.com/sean-/postgresql-acl/catalog"
+	"github.com/sean-/postgresql-acl/plan"
+	"github.com/sean-/postgresql-acl/policy"
+)
+
+// openDB connects to the database.  Tests replace it with a fake.
+var openDB = func(dsn string) (*sql.DB, error) {
+	return sql.Open("postgres", dsn)
+}
+
+// planFlags are the flags shared by plan and apply.
+type planFlags struct {
+	dsn     string
+	schemas string
+	compact bool
+}
+
+func (f *planFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.dsn, "dsn", "", "connection string; the PG* environment variables apply when empty")
+	fs.StringVar(&f.schemas, "schema", "", "comma-separated schemas to manage (default all but the system schemas)")
+	fs.BoolVar(&f.compact, "compact", true, "merge statements and use ALL ... IN SCHEMA where possible")
+}
+
+func (f *planFlags) schemaList() []string {
+	var schemas []string
+	for _, s := range strings.Split(f.schemas, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			schemas = append(schemas, s)
+		}
+	}
+
+	return schemas
+}
+
+func runPlan(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var flags planFlags
+	flags.register(fs)
+	out := fs.String("out", "", "save the plan to a file for pgacl apply")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: pgacl plan [-dsn dsn] [-schema a,b] [-out plan.json] policy.yaml")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Prints the GRANT, REVOKE and ALTER DEFAULT PRIVILEGES statements that")
+		fmt.Fprintln(stderr, "converge the database on the policy.  Only the privileges of the roles")
+		fmt.Fprintln(stderr, "named in the policy are changed.  A plan saved with -out can be applied")
+		fmt.Fprintln(stderr, "later and is refused if the ACLs have changed in the meantime.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	db, err := openDB(flags.dsn)
+	if err != nil {
+		fmt.Fprintf(stderr, "pgacl: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	schemas := flags.schemaList()
+	pl, err := computePlan(context.Background(), db, fs.Arg(0), flags)
+	if err != nil {
+		fmt.Fprintf(stderr, "pgacl: %v\n", err)
+		return 1
+	}
+
+	printPlan(stdout, pl.stmts)
+
+	if *out != "" {
+		if err := savePlan(*out, pl, schemas); err != nil {
+			fmt.Fprintf(stderr, "pgacl: %v\n", err)
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func savePlan(filename string, pl *planned, schemas []string) error {
+	f, err := plan.NewFile(pl.stmts, pl.desired, pl.actual, pl.roles, schemas)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := f.Write(out); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// planned is the outcome of planning a policy against a database.
+type planned struct {
+	stmts   []string
+	desired plan.State
+	actual  plan.State
+	roles   []string
+}
+
+// computePlan loads the policy and the catalog and returns the statements
+// that converge the database on the policy, along with the states they were
+// computed from.
+func computePlan(ctx context.Context, db *sql.DB, filename string, flags planFlags) (*planned, error) {
+	schemas := flags.schemaList()
+	p, err := policy.Load(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, err := loadState(ctx, db, schemas)
+	if err != nil {
+		return nil, err
+	}
+
+	objs, err := p.Expand(actual.Objects)
+	if err != nil {
+		return nil, err
+	}
+
+	pl := &planned{
+		desired: plan.State{Objects: objs, DefaultACLs: p.DefaultACLs()},
+		actual:  actual,
+		roles:   p.Roles(),
+	}
+	stmts := plan.Compute(pl.desired, pl.actual, pl.roles)
+	if flags.compact {
+		stmts = acl.Order(acl.Compact(stmts, actual.Objects))
+	}
+	pl.stmts = stmts.SQL()
+
+	return pl, nil
+}
+
+// loadState returns the privileges in the given schemas.
+func loadState(ctx context.Context, q catalog.Querier, schemas []string) (plan.State, error) {
+	c, err := catalog.Load(ctx, q, schemas...)
+	if err != nil {
+		return plan.State{}, err
+	}
+
+	return plan.State{Objects: c.Objects(), DefaultACLs: c.DefaultACLs}, nil
+}
+
+func printPlan(w io.Writer, stmts []string) {
+	if len(stmts) == 0 {
+		fmt.Fprintln(w, "-- no changes")
+		return
+	}
+
+	for _, stmt := range stmts {
+		fmt.Fprintf(w, "%s;\n", stmt)
+	}
+}
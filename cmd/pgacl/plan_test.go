@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sean-/postgresql-acl/internal/fakedb"
+)
+
+const testPolicy = `version: 1
+roles:
+  app_ro:
+    - on: table
+      in_schema: app
+      privileges: [SELECT]
+      defaults_for: [app]
+`
+
+const testPlan = `REVOKE UPDATE ON TABLE "app"."ledger" FROM "app_ro";
+GRANT SELECT ON TABLE "app"."accounts" TO "app_ro";
+ALTER DEFAULT PRIVILEGES FOR ROLE "app" IN SCHEMA "app" GRANT SELECT ON TABLES TO "app_ro";
+`
+
+// withDB points openDB at a fake database answering with results.
+func withDB(t *testing.T, results ...fakedb.Result) *fakedb.DB {
+	t.Helper()
+
+	fake := fakedb.New(results...)
+	orig := openDB
+	openDB = func(string) (*sql.DB, error) { return fake.Open(), nil }
+	t.Cleanup(func() { openDB = orig })
+
+	return fake
+}
+
+func writePolicy(t *testing.T) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(filename, []byte(testPolicy), 0o644); err != nil {
+		t.Fatalf("unable to write policy: %v", err)
+	}
+
+	return filename
+}
+
+// catalogResults answers the first catalog load with the state before apply
+// and later ones, such as the verification after apply, with the converged
+// state.
+var catalogResults = []fakedb.Result{
+	{
+		Match: "c.relkind IN",
+		Rows: [][]driver.Value{
+			{int64(16384), "app", "accounts", "r", "app", "{app=arwdDxt/app}"},
+			{int64(16385), "app", "ledger", "r", "app", "{app=arwdDxt/app,app_ro=rw/app}"},
+		},
+		Times: 1,
+	},
+	{
+		Match: "FROM pg_catalog.pg_default_acl d",
+		Times: 1,
+	},
+	{
+		Match: "c.relkind IN",
+		Rows: [][]driver.Value{
+			{int64(16384), "app", "accounts", "r", "app", "{app=arwdDxt/app,app_ro=r/app}"},
+			{int64(16385), "app", "ledger", "r", "app", "{app=arwdDxt/app,app_ro=r/app}"},
+		},
+	},
+	{
+		Match: "FROM pg_catalog.pg_default_acl d",
+		Rows: [][]driver.Value{
+			{"app", "app", "r", "{app_ro=r/app}"},
+		},
+	},
+}
+
+// statements returns the transaction control, GRANT, REVOKE and ALTER
+// statements in log.  Savepoints are left out; the executor tests cover them.
+func statements(log []string) []string {
+	var stmts []string
+	for _, s := range log {
+		if !strings.HasPrefix(s, "SELECT") && !strings.Contains(s, "SAVEPOINT") {
+			stmts = append(stmts, s)
+		}
+	}
+
+	return stmts
+}
+
+func TestPlan(t *testing.T) {
+	fake := withDB(t, catalogResults...)
+	filename := writePolicy(t)
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	if status := run([]string{"plan", "-schema", "app", filename}, strings.NewReader(""), stdout, stderr); status != 0 {
+		t.Fatalf("bad: expected status 0, got %d: %s", status, stderr)
+	}
+
+	if stdout.String() != testPlan {
+		t.Fatalf("bad: expected %q to equal %q", stdout, testPlan)
+	}
+
+	if stmts := statements(fake.Log()); len(stmts) != 0 {
+		t.Fatalf("bad: plan ran %q", stmts)
+	}
+}
+
+func TestApply(t *testing.T) {
+	want := []string{
+		"BEGIN",
+		`REVOKE UPDATE ON TABLE "app"."ledger" FROM "app_ro"`,
+		`GRANT SELECT ON TABLE "app"."accounts" TO "app_ro"`,
+		`ALTER DEFAULT PRIVILEGES FOR ROLE "app" IN SCHEMA "app" GRANT SELECT ON TABLES TO "app_ro"`,
+		"COMMIT",
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		stdin   string
+		results []fakedb.Result
+		status  int
+		stmts   []string
+		stderr  string
+	}{
+		{
+			name:   "confirmed",
+			stdin:  "y\n",
+			stmts:  want,
+			stderr: "applied 3 statements",
+		},
+		{
+			name:   "declined",
+			stdin:  "n\n",
+			status: 1,
+			stderr: "aborted",
+		},
+		{
+			name:  "yes",
+			args:  []string{"-yes"},
+			stmts: want,
+		},
+		{
+			name: "dry run",
+			args: []string{"--dry-run"},
+		},
+		{
+			name:    "failure rolls back",
+			args:    []string{"-yes"},
+			results: []fakedb.Result{{Match: "GRANT SELECT", Err: errors.New("permission denied")}},
+			status:  1,
+			stmts:   append(append([]string(nil), want[:3]...), "ROLLBACK"),
+			stderr:  "permission denied",
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			fake := withDB(t, append(test.results, catalogResults...)...)
+			args := append(append([]string{"apply"}, test.args...), writePolicy(t))
+
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+			if status := run(args, strings.NewReader(test.stdin), stdout, stderr); status != test.status {
+				t.Fatalf("bad: expected status %d, got %d: %s", test.status, status, stderr)
+			}
+
+			if stdout.String() != testPlan {
+				t.Fatalf("bad: expected %q to equal %q", stdout, testPlan)
+			}
+
+			if stmts := statements(fake.Log()); !reflect.DeepEqual(test.stmts, stmts) {
+				t.Fatalf("bad: expected %q to equal %q", test.stmts, stmts)
+			}
+
+			if !strings.Contains(stderr.String(), test.stderr) {
+				t.Fatalf("bad: expected %q to contain %q", stderr, test.stderr)
+			}
+		})
+	}
+}
+
+func TestApplyInvalidPlan(t *testing.T) {
+	tests := []struct {
+		name   string
+		plan   string
+		stderr string
+	}{
+		{
+			name:   "unsupported version",
+			plan:   `{"version": 2, "state_hash": "sha256:00", "statements": []}`,
+			stderr: "unsupported plan version 2",
+		},
+		{
+			name:   "missing hash",
+			plan:   `{"version": 1, "statements": []}`,
+			stderr: "plan has no state hash",
+		},
+		{
+			name:   "truncated",
+			plan:   `{"version": 1, "state_hash": "sha256:00", "statements": ["GRANT`,
+			stderr: "unable to decode plan",
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			fake := withDB(t, catalogResults...)
+			filename := filepath.Join(t.TempDir(), "plan.json")
+			if err := os.WriteFile(filename, []byte(test.plan), 0o644); err != nil {
+				t.Fatalf("unable to write plan: %v", err)
+			}
+
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+			if status := run([]string{"apply", filename}, strings.NewReader(""), stdout, stderr); status != 1 {
+				t.Fatalf("bad: expected status 1, got %d: %s", status, stderr)
+			}
+
+			if !strings.Contains(stderr.String(), test.stderr) {
+				t.Fatalf("bad: expected %q to contain %q", stderr, test.stderr)
+			}
+
+			if stmts := statements(fake.Log()); len(stmts) != 0 {
+				t.Fatalf("bad: apply ran %q", stmts)
+			}
+		})
+	}
+}
+
+func TestApplySavedPlan(t *testing.T) {
+	withDB(t, catalogResults...)
+	filename := filepath.Join(t.TempDir(), "plan.json")
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	if status := run([]string{"plan", "-out", filename, writePolicy(t)}, strings.NewReader(""), stdout, stderr); status != 0 {
+		t.Fatalf("bad: expected status 0, got %d: %s", status, stderr)
+	}
+
+	changed := []fakedb.Result{{
+		Match: "c.relkind IN",
+		Rows: [][]driver.Value{
+			{int64(16384), "app", "accounts", "r", "app", "{app=arwdDxt/app,app_ro=r/app}"},
+			{int64(16385), "app", "ledger", "r", "app", "{app=arwdDxt/app,app_ro=rw/app}"},
+		},
+	}}
+
+	// The database still shows the state the plan was made from after the
+	// plan is applied.
+	unconverged := []fakedb.Result{{
+		Match: "c.relkind IN",
+		Rows: [][]driver.Value{
+			{int64(16384), "app", "accounts", "r", "app", "{app=arwdDxt/app}"},
+			{int64(16385), "app", "ledger", "r", "app", "{app=arwdDxt/app,app_ro=rw/app}"},
+		},
+		Times: 2,
+	}}
+
+	applied := []string{
+		"BEGIN",
+		`REVOKE UPDATE ON TABLE "app"."ledger" FROM "app_ro"`,
+		`GRANT SELECT ON TABLE "app"."accounts" TO "app_ro"`,
+		`ALTER DEFAULT PRIVILEGES FOR ROLE "app" IN SCHEMA "app" GRANT SELECT ON TABLES TO "app_ro"`,
+		"COMMIT",
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		results []fakedb.Result
+		status  int
+		stmts   []string
+		stderr  string
+	}{
+		{
+			name:   "unchanged",
+			stmts:  applied,
+			stderr: "applied 3 statements",
+		},
+		{
+			name:    "not converged",
+			results: unconverged,
+			status:  1,
+			stmts:   applied,
+			stderr:  "verification failed: 2 statements still pending",
+		},
+		{
+			name:    "stale",
+			results: changed,
+			status:  1,
+			stmts:   []string{"BEGIN", "ROLLBACK"},
+			stderr:  "ACLs have changed since the plan was made",
+		},
+		{
+			name:  "dry run",
+			args:  []string{"-dry-run"},
+			stmts: []string{"BEGIN", "ROLLBACK"},
+		},
+		{
+			name:   "schema flag",
+			args:   []string{"-schema", "app"},
+			status: 2,
+			stderr: "-schema cannot be used with a saved plan",
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			fake := withDB(t, append(test.results, catalogResults...)...)
+			args := append(append([]string{"apply"}, test.args...), filename)
+
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+			if status := run(args, strings.NewReader(""), stdout, stderr); status != test.status {
+				t.Fatalf("bad: expected status %d, got %d: %s", test.status, status, stderr)
+			}
+
+			if stmts := statements(fake.Log()); !reflect.DeepEqual(test.stmts, stmts) {
+				t.Fatalf("bad: expected %q to equal %q", test.stmts, stmts)
+			}
+
+			if !strings.Contains(stderr.String(), test.stderr) {
+				t.Fatalf("bad: expected %q to contain %q", stderr, test.stderr)
+			}
+		})
+	}
+}
@@ -1,7 +1,5 @@
 package acl
 
-import "fmt"
-
 // Column models the privileges of a column aclitem
 type Column struct {
 	ACL
@@ -9,8 +7,8 @@ type Column struct {
 
 // NewColumn parses an ACL object and returns a Column object.
 func NewColumn(acl ACL) (Column, error) {
-	if !validRights(acl, validColumnPrivs) {
-		return Column{}, fmt.Errorf("invalid flags set for column (%+q), only %+q allowed", permString(acl.Privileges, acl.GrantOptions), validColumnPrivs)
+	if err := validRights(acl, validColumnPrivs, "column"); err != nil {
+		return Column{}, err
 	}
 
 	return Column{ACL: acl}, nil
@@ -0,0 +1,235 @@
+package acl
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Compact merges statements whose combined effect is identical to running
+// them one by one:
+//
+//   - statements that differ only in their privileges are merged into one
+//     statement with all of the privileges,
+//   - then statements that differ only in their grantees,
+//   - then statements on objects of the same kind, and on columns of the same
+//     relation, that are otherwise identical.
+//
+// Only consecutive statements with the same action are merged, so the order
+// of grants relative to revokes is preserved.  Statements on every table,
+// sequence or function of a schema listed in inventory are replaced with
+// ALL ... IN SCHEMA.  ALL ... IN SCHEMA applies to the objects present when
+// the statement runs, so inventory must be current; pass nil to disable it.
+func Compact(stmts Statements, inventory []Object) Statements {
+	schemas := schemaContents(inventory)
+
+	var out Statements
+	for len(stmts) > 0 {
+		n := 1
+		for n < len(stmts) && stmts[n].Action == stmts[0].Action {
+			n++
+		}
+
+		run := merge(stmts[:n], Statement.privilegeGroup, func(into *Statement, s Statement) {
+			into.Privileges |= s.Privileges
+		})
+		run = merge(run, Statement.granteeGroup, func(into *Statement, s Statement) {
+			into.Grantees = appendUnique(into.Grantees, s.Grantees, func(r string) string { return r })
+		})
+		run = merge(run, Statement.objectGroup, mergeObjects)
+		run = allInSchema(run, schemas)
+		run = merge(run, Statement.objectGroup, mergeObjects)
+
+		out = append(out, run...)
+		stmts = stmts[n:]
+	}
+
+	return out
+}
+
+// merge combines the statements that share a group, keeping the position of
+// the first.  Statements in the empty group are never combined.
+func merge(stmts Statements, group func(Statement) string, combine func(*Statement, Statement)) Statements {
+	out := make(Statements, 0, len(stmts))
+	index := make(map[string]int)
+	for _, s := range stmts {
+		s.Grantees = append([]string(nil), s.Grantees...)
+		s.Objects = append([]Object(nil), s.Objects...)
+
+		g := group(s)
+		if i, ok := index[g]; ok && g != "" {
+			combine(&out[i], s)
+			continue
+		}
+
+		index[g] = len(out)
+		out = append(out, s)
+	}
+
+	return out
+}
+
+func mergeObjects(into *Statement, s Statement) {
+	key := Object.Key
+	if into.AllInSchema {
+		key = func(o Object) string { return o.Schema }
+	}
+
+	into.Objects = appendUnique(into.Objects, s.Objects, key)
+}
+
+// appendUnique appends the elements of src whose key is not already in dst.
+func appendUnique[T any](dst, src []T, key func(T) string) []T {
+	seen := make(map[string]bool, len(dst))
+	for _, v := range dst {
+		seen[key(v)] = true
+	}
+
+	for _, v := range src {
+		if k := key(v); !seen[k] {
+			seen[k] = true
+			dst = append(dst, v)
+		}
+	}
+
+	return dst
+}
+
+// privilegeGroup identifies the statements that can be merged by combining
+// their privileges.
+func (s Statement) privilegeGroup() string {
+	return s.group(s.objectsKey(), s.granteesKey())
+}
+
+// granteeGroup identifies the statements that can be merged by combining
+// their grantees.
+func (s Statement) granteeGroup() string {
+	return s.group(s.objectsKey(), strconv.FormatUint(uint64(s.Privileges), 10))
+}
+
+// objectGroup identifies the statements that can be merged by combining
+// their objects.  Default privileges are never merged this way, and column
+// statements only with those on the same relation.
+func (s Statement) objectGroup() string {
+	if s.isDefault() {
+		return ""
+	}
+
+	target := string(s.kind())
+	if s.kind() == KindColumn {
+		target += ":" + s.Objects[0].identity()
+	}
+
+	return s.group(target, strconv.FormatUint(uint64(s.Privileges), 10), s.granteesKey())
+}
+
+// group joins the fields every merge must agree on with extra.
+func (s Statement) group(extra ...string) string {
+	fields := append([]string{
+		string(s.Action),
+		strconv.FormatBool(s.WithGrantOption),
+		strconv.FormatBool(s.AllInSchema),
+		strconv.FormatBool(s.Cascade),
+		strconv.FormatBool(s.isDefault()),
+		s.GrantedBy,
+		s.DefaultFor,
+		strings.Join(s.Roles, "\x01"),
+	}, extra...)
+
+	return strings.Join(fields, "\x00")
+}
+
+func (s Statement) objectsKey() string {
+	keys := make([]string, 0, len(s.Objects))
+	for _, o := range s.Objects {
+		if s.AllInSchema || s.isDefault() {
+			keys = append(keys, string(o.Kind)+":"+o.Schema)
+		} else {
+			keys = append(keys, o.Key())
+		}
+	}
+	sort.Strings(keys)
+
+	return strings.Join(keys, "\x01")
+}
+
+func (s Statement) granteesKey() string {
+	roles := append([]string(nil), s.Grantees...)
+	sort.Strings(roles)
+
+	return strings.Join(roles, "\x01")
+}
+
+// schemaContents returns the keys of the tables, sequences, functions and
+// procedures in inventory by kind and schema.  Procedures are kept apart from
+// functions, as ALL FUNCTIONS IN SCHEMA does not grant on them.
+func schemaContents(inventory []Object) map[string]map[string]bool {
+	contents := make(map[string]map[string]bool)
+	for _, o := range inventory {
+		switch o.Kind {
+		case KindTable, KindSequence, KindFunction, KindProcedure:
+		default:
+			continue
+		}
+
+		k := string(o.Kind) + ":" + o.Schema
+		if contents[k] == nil {
+			contents[k] = make(map[string]bool)
+		}
+		contents[k][o.Key()] = true
+	}
+
+	return contents
+}
+
+// allInSchema splits the objects of each statement that covers every object
+// of its kind in a schema into an ALL ... IN SCHEMA statement.
+func allInSchema(stmts Statements, contents map[string]map[string]bool) Statements {
+	if len(contents) == 0 {
+		return stmts
+	}
+
+	var out Statements
+	for _, s := range stmts {
+		if s.isDefault() || s.AllInSchema {
+			out = append(out, s)
+			continue
+		}
+
+		covered := make(map[string]map[string]bool)
+		for _, o := range s.Objects {
+			k := string(o.Kind) + ":" + o.Schema
+			if contents[k][o.Key()] {
+				if covered[k] == nil {
+					covered[k] = make(map[string]bool)
+				}
+				covered[k][o.Key()] = true
+			}
+		}
+
+		var rest, all []Object
+		for _, o := range s.Objects {
+			k := string(o.Kind) + ":" + o.Schema
+			if len(contents[k]) == 0 || len(covered[k]) < len(contents[k]) {
+				rest = append(rest, o)
+				continue
+			}
+
+			all = appendUnique(all, []Object{{Kind: o.Kind, Schema: o.Schema}}, func(o Object) string { return o.Schema })
+		}
+
+		if len(all) > 0 {
+			whole := s
+			whole.Objects = all
+			whole.AllInSchema = true
+			out = append(out, whole)
+		}
+
+		if len(rest) > 0 {
+			s.Objects = rest
+			out = append(out, s)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,161 @@
+package acl_test
+
+import (
+	"reflect"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+func TestCompact(t *testing.T) {
+	table := func(schema, name string) acl.Object {
+		return acl.Object{Kind: acl.KindTable, Schema: schema, Name: name, Owner: "app"}
+	}
+
+	acls := func(s ...string) []acl.ACL {
+		var out []acl.ACL
+		for _, item := range s {
+			a, err := acl.Parse(item)
+			if err != nil {
+				t.Fatalf("unable to parse %+q: %v", item, err)
+			}
+			out = append(out, a)
+		}
+		return out
+	}
+
+	grants := func(objs ...acl.Object) acl.Statements {
+		var stmts acl.Statements
+		for _, o := range objs {
+			stmts = append(stmts, o.Grants()...)
+		}
+		return stmts
+	}
+
+	withACL := func(o acl.Object, items ...string) acl.Object {
+		o.ACL = acls(items...)
+		return o
+	}
+
+	inventory := []acl.Object{
+		table("app", "accounts"),
+		table("app", "ledger"),
+		table("billing", "invoices"),
+		table("billing", "payments"),
+		{Kind: acl.KindSequence, Schema: "app", Name: "ids"},
+		{Kind: acl.KindFunction, Schema: "app", Name: "balance", Signature: "integer"},
+		{Kind: acl.KindProcedure, Schema: "app", Name: "close_month", Signature: "date"},
+	}
+
+	tests := []struct {
+		name      string
+		stmts     acl.Statements
+		inventory []acl.Object
+		want      []string
+	}{
+		{
+			name:  "privileges",
+			stmts: grants(withACL(table("app", "accounts"), "ro=arw/app")),
+			want:  []string{`GRANT INSERT, SELECT, UPDATE ON TABLE "app"."accounts" TO "ro"`},
+		},
+		{
+			name:  "grant option kept apart",
+			stmts: grants(withACL(table("app", "accounts"), "rw=a*r*w/app")),
+			want: []string{
+				`GRANT INSERT, SELECT ON TABLE "app"."accounts" TO "rw" WITH GRANT OPTION`,
+				`GRANT UPDATE ON TABLE "app"."accounts" TO "rw"`,
+			},
+		},
+		{
+			name: "privileges, grantees and objects",
+			stmts: grants(
+				withACL(table("app", "accounts"), "r1=arw*/app", "r2=arw*/app"),
+				withACL(table("app", "ledger"), "r1=arw*/app", "r2=arw*/app"),
+			),
+			want: []string{
+				`GRANT INSERT, SELECT ON TABLE "app"."accounts", "app"."ledger" TO "r1", "r2"`,
+				`GRANT UPDATE ON TABLE "app"."accounts", "app"."ledger" TO "r1", "r2" WITH GRANT OPTION`,
+			},
+		},
+		{
+			name: "columns of one relation",
+			stmts: grants(
+				acl.Object{Kind: acl.KindColumn, Schema: "app", Name: "users", Column: "email", ACL: acls("ro=r/app")},
+				acl.Object{Kind: acl.KindColumn, Schema: "app", Name: "users", Column: "name", ACL: acls("ro=r/app")},
+				acl.Object{Kind: acl.KindColumn, Schema: "app", Name: "orders", Column: "total", ACL: acls("ro=r/app")},
+			),
+			want: []string{
+				`GRANT SELECT ("email", "name") ON TABLE "app"."users" TO "ro"`,
+				`GRANT SELECT ("total") ON TABLE "app"."orders" TO "ro"`,
+			},
+		},
+		{
+			name: "all in schema",
+			stmts: grants(
+				withACL(table("app", "accounts"), "ro=r/app"),
+				withACL(table("app", "ledger"), "ro=r/app"),
+				withACL(table("billing", "invoices"), "ro=r/app"),
+				withACL(table("billing", "payments"), "ro=r/app"),
+			),
+			inventory: inventory,
+			want:      []string{`GRANT SELECT ON ALL TABLES IN SCHEMA "app", "billing" TO "ro"`},
+		},
+		{
+			name: "partly covered schema",
+			stmts: grants(
+				withACL(table("app", "accounts"), "ro=r/app"),
+				withACL(table("app", "ledger"), "ro=r/app"),
+				withACL(table("billing", "invoices"), "ro=r/app"),
+			),
+			inventory: inventory,
+			want: []string{
+				`GRANT SELECT ON ALL TABLES IN SCHEMA "app" TO "ro"`,
+				`GRANT SELECT ON TABLE "billing"."invoices" TO "ro"`,
+			},
+		},
+		{
+			name: "functions and procedures",
+			stmts: grants(
+				acl.Object{Kind: acl.KindFunction, Schema: "app", Name: "balance", Signature: "integer", ACL: acls("ops=X/app")},
+				acl.Object{Kind: acl.KindProcedure, Schema: "app", Name: "close_month", Signature: "date", ACL: acls("ops=X/app")},
+			),
+			inventory: inventory,
+			want: []string{
+				`GRANT EXECUTE ON ALL FUNCTIONS IN SCHEMA "app" TO "ops"`,
+				`GRANT EXECUTE ON ALL PROCEDURES IN SCHEMA "app" TO "ops"`,
+			},
+		},
+		{
+			name: "revokes and grants are not reordered",
+			stmts: append(append(
+				withACL(table("app", "accounts"), "ro=w/app").Revokes(),
+				withACL(table("app", "accounts"), "ro=r/app").Grants()...),
+				withACL(table("app", "ledger"), "ro=w/app").Revokes()...),
+			want: []string{
+				`REVOKE UPDATE ON TABLE "app"."accounts" FROM "ro"`,
+				`GRANT SELECT ON TABLE "app"."accounts" TO "ro"`,
+				`REVOKE UPDATE ON TABLE "app"."ledger" FROM "ro"`,
+			},
+		},
+		{
+			name: "default privileges",
+			stmts: acl.DefaultACL{
+				Role: "app", Schema: "app", Kind: acl.KindTable, ACL: acls("r1=ar/app", "r2=ar/app"),
+			}.Grants(),
+			want: []string{`ALTER DEFAULT PRIVILEGES FOR ROLE "app" IN SCHEMA "app" GRANT INSERT, SELECT ON TABLES TO "r1", "r2"`},
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			got := acl.Compact(test.stmts, test.inventory).SQL()
+			if !reflect.DeepEqual(test.want, got) {
+				t.Fatalf("bad: expected %#v to equal %#v", test.want, got)
+			}
+		})
+	}
+}
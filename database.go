@@ -1,7 +1,5 @@
 package acl
 
-import "fmt"
-
 // Database models the privileges of a database aclitem
 type Database struct {
 	ACL
@@ -9,8 +7,8 @@ type Database struct {
 
 // NewDatabase parses an ACL object and returns a Database object.
 func NewDatabase(acl ACL) (Database, error) {
-	if !validRights(acl, validDatabasePrivs) {
-		return Database{}, fmt.Errorf("invalid flags set for database (%+q), only %+q allowed", permString(acl.Privileges, acl.GrantOptions), validDatabasePrivs)
+	if err := validRights(acl, validDatabasePrivs, "database"); err != nil {
+		return Database{}, err
 	}
 
 	return Database{ACL: acl}, nil
@@ -0,0 +1,86 @@
+package acl
+
+import "fmt"
+
+// defaultACLKeywords maps the object kinds that support default privileges to
+// the plural keyword used by ALTER DEFAULT PRIVILEGES.  PostgreSQL stores
+// default privileges on routines as those on functions, which also apply to
+// procedures.
+var defaultACLKeywords = map[ObjectKind]string{
+	KindFunction:    "FUNCTIONS",
+	KindLargeObject: "LARGE OBJECTS",
+	KindRoutine:     "ROUTINES",
+	KindSchema:      "SCHEMAS",
+	KindSequence:    "SEQUENCES",
+	KindTable:       "TABLES",
+	KindType:        "TYPES",
+}
+
+// DefaultACL models a pg_default_acl entry: the privileges applied to objects
+// of Kind created by Role, optionally only within Schema.
+type DefaultACL struct {
+	Role string `json:"role"`
+
+	// Schema is empty for defaults that apply to the whole database.
+	Schema string     `json:"schema,omitempty"`
+	Kind   ObjectKind `json:"kind"`
+	ACL    []ACL      `json:"acl"`
+}
+
+// Key returns a string that uniquely identifies the default ACL entry.
+func (d DefaultACL) Key() string {
+	key := fmt.Sprintf("default:%s:role=%s", d.Kind, d.Role)
+	if d.Schema != "" {
+		key += ":schema=" + d.Schema
+	}
+
+	return key
+}
+
+// Validate checks that the entry has a role, that the kind supports default
+// privileges and that every entry in the ACL list is valid for it.
+func (d DefaultACL) Validate() error {
+	if d.Role == "" {
+		return fmt.Errorf("%s: default privileges need a role", d.Key())
+	}
+
+	if _, ok := defaultACLKeywords[d.Kind]; !ok {
+		return fmt.Errorf("%s: default privileges are not supported for %s", d.Key(), d.Kind)
+	}
+
+	if d.Kind == KindSchema && d.Schema != "" {
+		return fmt.Errorf("%s: default privileges for schemas cannot be limited to a schema", d.Key())
+	}
+
+	for _, acl := range d.ACL {
+		if err := d.Kind.Validate(acl); err != nil {
+			return fmt.Errorf("%s: %w", d.Key(), err)
+		}
+	}
+
+	return nil
+}
+
+// Grants returns the ALTER DEFAULT PRIVILEGES statements that constitute the
+// privileges specified in the ACL list.  Without a Role they apply to the
+// current role.
+func (d DefaultACL) Grants() Statements {
+	return d.statements(Grant)
+}
+
+// Revokes returns the ALTER DEFAULT PRIVILEGES statements that remove the
+// privileges specified in the ACL list.  Privileges held with the grant
+// option only have the grant option revoked.
+func (d DefaultACL) Revokes() Statements {
+	return d.statements(Revoke)
+}
+
+// statements returns the ALTER DEFAULT PRIVILEGES statements for action.
+func (d DefaultACL) statements(action Action) Statements {
+	stmts := statements(action, Object{Kind: d.Kind, Schema: d.Schema}, d.Role, d.ACL)
+	for i := range stmts {
+		stmts[i].Default = true
+	}
+
+	return stmts
+}
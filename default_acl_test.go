@@ -0,0 +1,174 @@
+package acl_test
+
+import (
+	"reflect"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+func TestDefaultACL(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     acl.DefaultACL
+		key     string
+		grants  []string
+		revokes []string
+		fail    bool
+	}{
+		{
+			name: "tables in schema",
+			def: acl.DefaultACL{
+				Role:   "app",
+				Schema: "public",
+				Kind:   acl.KindTable,
+				ACL:    []acl.ACL{{Role: "ro", Privileges: acl.Select | acl.References, GrantOptions: acl.References}},
+			},
+			key: "default:table:role=app:schema=public",
+			grants: []string{
+				`ALTER DEFAULT PRIVILEGES FOR ROLE "app" IN SCHEMA "public" GRANT REFERENCES ON TABLES TO "ro" WITH GRANT OPTION`,
+				`ALTER DEFAULT PRIVILEGES FOR ROLE "app" IN SCHEMA "public" GRANT SELECT ON TABLES TO "ro"`,
+			},
+			revokes: []string{
+				`ALTER DEFAULT PRIVILEGES FOR ROLE "app" IN SCHEMA "public" REVOKE GRANT OPTION FOR REFERENCES ON TABLES FROM "ro"`,
+				`ALTER DEFAULT PRIVILEGES FOR ROLE "app" IN SCHEMA "public" REVOKE SELECT ON TABLES FROM "ro"`,
+			},
+		},
+		{
+			name: "functions everywhere",
+			def: acl.DefaultACL{
+				Role: "app",
+				Kind: acl.KindFunction,
+				ACL:  []acl.ACL{{Privileges: acl.Execute}},
+			},
+			key:     "default:function:role=app",
+			grants:  []string{`ALTER DEFAULT PRIVILEGES FOR ROLE "app" GRANT EXECUTE ON FUNCTIONS TO PUBLIC`},
+			revokes: []string{`ALTER DEFAULT PRIVILEGES FOR ROLE "app" REVOKE EXECUTE ON FUNCTIONS FROM PUBLIC`},
+		},
+		{
+			name: "unsupported kind",
+			def: acl.DefaultACL{
+				Role: "app",
+				Kind: acl.KindDatabase,
+				ACL:  []acl.ACL{{Privileges: acl.Connect}},
+			},
+			key:  "default:database:role=app",
+			fail: true,
+		},
+		{
+			name: "schemas in schema",
+			def: acl.DefaultACL{
+				Role:   "app",
+				Schema: "public",
+				Kind:   acl.KindSchema,
+				ACL:    []acl.ACL{{Privileges: acl.Usage}},
+			},
+			key:  "default:schema:role=app:schema=public",
+			fail: true,
+		},
+		{
+			name: "invalid privilege",
+			def: acl.DefaultACL{
+				Role: "app",
+				Kind: acl.KindSequence,
+				ACL:  []acl.ACL{{Privileges: acl.Insert}},
+			},
+			key:  "default:sequence:role=app",
+			fail: true,
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			if key := test.def.Key(); key != test.key {
+				t.Fatalf("want %+q got %+q", test.key, key)
+			}
+
+			err := test.def.Validate()
+			if err != nil && !test.fail {
+				t.Fatalf("unable to validate default ACL: %v", err)
+			}
+
+			if err == nil && test.fail {
+				t.Fatalf("expected failure")
+			}
+
+			if test.fail {
+				return
+			}
+
+			if grants := test.def.Grants().SQL(); !reflect.DeepEqual(test.grants, grants) {
+				t.Fatalf("bad: expected %#v to equal %#v", test.grants, grants)
+			}
+
+			if revokes := test.def.Revokes().SQL(); !reflect.DeepEqual(test.revokes, revokes) {
+				t.Fatalf("bad: expected %#v to equal %#v", test.revokes, revokes)
+			}
+		})
+	}
+}
+
+func TestDefaultACLWithoutRole(t *testing.T) {
+	def := acl.DefaultACL{
+		Schema: "app",
+		Kind:   acl.KindTable,
+		ACL:    []acl.ACL{{Role: "ro", Privileges: acl.Select}},
+	}
+
+	if err := def.Validate(); err == nil {
+		t.Fatalf("expected failure")
+	}
+
+	want := []string{`ALTER DEFAULT PRIVILEGES IN SCHEMA "app" GRANT SELECT ON TABLES TO "ro"`}
+	if grants := def.Grants().SQL(); !reflect.DeepEqual(want, grants) {
+		t.Fatalf("bad: expected %#v to equal %#v", want, grants)
+	}
+}
+
+func TestDefaultACLDumpSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		def  acl.DefaultACL
+		want string
+	}{
+		{
+			name: "schema",
+			def: acl.DefaultACL{Role: "app", Schema: "app", Kind: acl.KindTable, ACL: []acl.ACL{
+				{Role: "ro", Privileges: acl.Select, GrantedBy: "app"},
+			}},
+			want: "ALTER DEFAULT PRIVILEGES FOR ROLE app IN SCHEMA app GRANT SELECT ON TABLES TO ro;\n",
+		},
+		{
+			name: "global revoke",
+			def: acl.DefaultACL{Role: "app", Kind: acl.KindFunction, ACL: []acl.ACL{
+				{Role: "app", Privileges: acl.Execute, GrantedBy: "app"},
+			}},
+			want: "ALTER DEFAULT PRIVILEGES FOR ROLE app REVOKE ALL ON FUNCTIONS FROM PUBLIC;\n",
+		},
+		{
+			name: "global grant",
+			def: acl.DefaultACL{Role: "Owner", Kind: acl.KindSequence, ACL: []acl.ACL{
+				{Role: "Owner", Privileges: acl.Select | acl.Update | acl.Usage, GrantedBy: "Owner"},
+				{Role: "rw", Privileges: acl.Select | acl.Usage, GrantOptions: acl.Usage, GrantedBy: "Owner"},
+			}},
+			want: `ALTER DEFAULT PRIVILEGES FOR ROLE "Owner" GRANT SELECT ON SEQUENCES TO rw;` + "\n" +
+				`ALTER DEFAULT PRIVILEGES FOR ROLE "Owner" GRANT USAGE ON SEQUENCES TO rw WITH GRANT OPTION;` + "\n",
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.def.DumpSQL(); got != test.want {
+				t.Fatalf("want %+q got %+q", test.want, got)
+			}
+		})
+	}
+}
@@ -1,7 +1,5 @@
 package acl
 
-import "fmt"
-
 // Domain models the privileges of a domain aclitem
 type Domain struct {
 	ACL
@@ -9,8 +7,8 @@ type Domain struct {
 
 // NewDomain parses an ACL object and returns a Domain object.
 func NewDomain(acl ACL) (Domain, error) {
-	if !validRights(acl, validDomainPrivs) {
-		return Domain{}, fmt.Errorf("invalid flags set for domain (%+q), only %+q allowed", permString(acl.Privileges, acl.GrantOptions), validDomainPrivs)
+	if err := validRights(acl, validDomainPrivs, "domain"); err != nil {
+		return Domain{}, err
 	}
 
 	return Domain{ACL: acl}, nil
@@ -0,0 +1,533 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Dump is the privilege state described by a pg_dump script.
+type Dump struct {
+	// Objects holds every object whose owner or privileges the script sets.
+	// Objects without GRANT or REVOKE statements have the ACLDefault of
+	// their owner, as they would in the restored database.
+	Objects []Object
+
+	// DefaultACLs holds the default privileges set by the script in the form
+	// pg_default_acl stores them.
+	DefaultACLs []DefaultACL
+}
+
+// ownerKeywords maps the object type keywords of ALTER ... OWNER TO to
+// object kinds.  Longer keyword sequences are listed first.
+var ownerKeywords = []struct {
+	words []string
+	kind  ObjectKind
+}{
+	{[]string{"foreign", "data", "wrapper"}, KindForeignDataWrapper},
+	{[]string{"materialized", "view"}, KindTable},
+	{[]string{"foreign", "table"}, KindTable},
+	{[]string{"procedural", "language"}, KindLanguage},
+	{[]string{"large", "object"}, KindLargeObject},
+	{[]string{"aggregate"}, KindFunction},
+	{[]string{"database"}, KindDatabase},
+	{[]string{"domain"}, KindDomain},
+	{[]string{"function"}, KindFunction},
+	{[]string{"language"}, KindLanguage},
+	{[]string{"procedure"}, KindProcedure},
+	{[]string{"routine"}, KindRoutine},
+	{[]string{"schema"}, KindSchema},
+	{[]string{"sequence"}, KindSequence},
+	{[]string{"server"}, KindForeignServer},
+	{[]string{"table"}, KindTable},
+	{[]string{"tablespace"}, KindTablespace},
+	{[]string{"type"}, KindType},
+	{[]string{"view"}, KindTable},
+}
+
+// ReadDump reads a plain-format pg_dump script, e.g. the output of pg_dump
+// --schema-only, and replays its ALTER ... OWNER TO, GRANT, REVOKE and ALTER
+// DEFAULT PRIVILEGES statements to rebuild the ACL list of every object in
+// the form Parse returns.
+//
+// Privileges granted while SET SESSION AUTHORIZATION or SET ROLE is in effect
+// are recorded with that role as grantor and all others with the object's
+// owner, matching what a restore as superuser produces.  GRANT ON TYPE
+// applies to a domain of the same name, as pg_dump writes domain privileges
+// that way.  Role memberships, the data of COPY ... FROM stdin statements
+// and all other statements are skipped.
+//
+// Statements that cannot be parsed are reported as *ScriptError values
+// joined with errors.Join, alongside the privileges that could be rebuilt.
+func ReadDump(r io.Reader) (*Dump, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read dump: %w", err)
+	}
+
+	toks, err := lex(skipCopyData(string(src)))
+	if err != nil {
+		return nil, err
+	}
+
+	d := newDumpState()
+	var errs []error
+	for _, stmt := range splitStatements(toks) {
+		if err := d.exec(stmt); err != nil {
+			errs = append(errs, &ScriptError{Line: stmt[0].line, Err: err})
+		}
+	}
+
+	return d.dump(), errors.Join(errs...)
+}
+
+// skipCopyData blanks the data lines that follow each COPY ... FROM stdin
+// statement of src, up to and including the \. line that ends them, so rows
+// holding quotes or semicolons are not lexed as SQL.  Line breaks are kept
+// so errors report the lines of the dump.
+func skipCopyData(src string) string {
+	lines := strings.SplitAfter(src, "\n")
+	inData := false
+	for i, line := range lines {
+		text := strings.TrimRight(line, "\r\n")
+		switch {
+		case inData:
+			inData = text != `\.`
+			lines[i] = line[len(text):]
+		case hasWordPrefix(text, "copy") && strings.HasSuffix(strings.ToLower(text), " from stdin;"):
+			inData = true
+		}
+	}
+
+	return strings.Join(lines, "")
+}
+
+// hasWordPrefix reports whether s starts with the keyword word, matched
+// case-insensitively and followed by whitespace.
+func hasWordPrefix(s, word string) bool {
+	return len(s) > len(word) && strings.EqualFold(s[:len(word)], word) &&
+		(s[len(word)] == ' ' || s[len(word)] == '\t')
+}
+
+// dumpState replays the privilege statements of a dump.  Objects and default
+// privileges are kept in the order they first appear.
+type dumpState struct {
+	objects     map[string]*dumpObject
+	objectKeys  []string
+	defaults    map[string]*DefaultACL
+	defaultKeys []string
+
+	// sequences holds the keys of the sequences the dump creates.
+	sequences map[string]bool
+
+	// session and role are set by SET SESSION AUTHORIZATION and SET ROLE.
+	session string
+	role    string
+}
+
+func newDumpState() *dumpState {
+	return &dumpState{
+		objects:   make(map[string]*dumpObject),
+		defaults:  make(map[string]*DefaultACL),
+		sequences: make(map[string]bool),
+	}
+}
+
+// dumpObject is an object of a dump.  Its ACL starts out as the ACLDefault
+// of its owner when a GRANT or REVOKE first changes it.
+type dumpObject struct {
+	Object
+	changed bool
+}
+
+// exec replays one statement of the dump.
+func (d *dumpState) exec(toks []token) error {
+	if isACLStatement(toks) {
+		stmts, err := parseTokens(toks)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range stmts {
+			if err := d.apply(s); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	p := &stmtParser{toks: toks}
+	switch {
+	case p.words("alter"):
+		if obj, owner, ok := p.owner(); ok {
+			d.setOwner(obj, owner)
+		}
+	case p.words("create", "sequence"):
+		p.words("if", "not", "exists")
+		if obj, err := p.object(KindSequence); err == nil {
+			d.sequences[obj.Key()] = true
+		}
+	case p.words("set", "session", "authorization"):
+		d.session, d.role = p.setting(), ""
+	case p.words("reset", "session", "authorization"):
+		d.session, d.role = "", ""
+	case p.words("set", "role"):
+		d.role = p.setting()
+	case p.words("reset", "role"):
+		d.role = ""
+	}
+
+	return nil
+}
+
+// owner parses the remainder of an ALTER ... OWNER TO statement.  ok is false
+// for every other ALTER statement.
+func (p *stmtParser) owner() (obj Object, owner string, ok bool) {
+	kind := ObjectKind("")
+	for _, k := range ownerKeywords {
+		if p.words(k.words...) {
+			kind = k.kind
+			break
+		}
+	}
+
+	if kind == "" {
+		return Object{}, "", false
+	}
+
+	obj, err := p.object(kind)
+	if err != nil || !p.words("owner", "to") {
+		return Object{}, "", false
+	}
+
+	if owner, err = p.ident(); err != nil || p.peek().kind != tokEOF {
+		return Object{}, "", false
+	}
+
+	return obj, owner, true
+}
+
+// setting returns the value of a SET statement: a role name, or "" for
+// DEFAULT and NONE.
+func (p *stmtParser) setting() string {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		value := strings.TrimPrefix(strings.TrimPrefix(t.text, "E"), "e")
+		return strings.ReplaceAll(strings.Trim(value, "'"), "''", "'")
+	case tokWord:
+		if t.value == "default" || t.value == "none" {
+			return ""
+		}
+	}
+
+	return t.value
+}
+
+// object returns the dump's entry for o, adding it if it is new.  Types are
+// looked up as domains first, and routines as procedures if one is known and
+// as functions otherwise.  pg_dump sets the owner of sequences with ALTER
+// TABLE, so tables and sequences share one entry per name: tables resolve to
+// a created or known sequence, and a sequence takes over a table entry.
+func (d *dumpState) object(o Object) *dumpObject {
+	switch o.Kind {
+	case KindTable:
+		seq := o
+		seq.Kind = KindSequence
+		if obj, ok := d.objects[seq.Key()]; ok {
+			return obj
+		}
+		if d.sequences[seq.Key()] {
+			o.Kind = KindSequence
+		}
+	case KindSequence:
+		table := o
+		table.Kind = KindTable
+		if obj, ok := d.objects[table.Key()]; ok {
+			d.rekey(obj, KindSequence)
+			return obj
+		}
+	case KindType:
+		domain := o
+		domain.Kind = KindDomain
+		if obj, ok := d.objects[domain.Key()]; ok {
+			return obj
+		}
+	case KindRoutine:
+		o.Kind = KindProcedure
+		if obj, ok := d.objects[o.Key()]; ok {
+			return obj
+		}
+		o.Kind = KindFunction
+	}
+
+	key := o.Key()
+	obj, ok := d.objects[key]
+	if !ok {
+		obj = &dumpObject{Object: Object{
+			Kind:      o.Kind,
+			Schema:    o.Schema,
+			Name:      o.Name,
+			Column:    o.Column,
+			Signature: o.Signature,
+			OID:       o.OID,
+		}}
+		d.objects[key] = obj
+		d.objectKeys = append(d.objectKeys, key)
+	}
+
+	return obj
+}
+
+// rekey changes the kind of obj, keeping its place in the dump.
+func (d *dumpState) rekey(obj *dumpObject, kind ObjectKind) {
+	old := obj.Key()
+	obj.Kind = kind
+	key := obj.Key()
+
+	delete(d.objects, old)
+	d.objects[key] = obj
+	for i, k := range d.objectKeys {
+		if k == old {
+			d.objectKeys[i] = key
+		}
+	}
+}
+
+// ownerOf returns the owner of obj, which for columns is the owner of their
+// relation.
+func (d *dumpState) ownerOf(obj *dumpObject) string {
+	if obj.Kind != KindColumn {
+		return obj.Owner
+	}
+
+	rel := Object{Kind: KindTable, Schema: obj.Schema, Name: obj.Name}
+	if r, ok := d.objects[rel.Key()]; ok {
+		return r.Owner
+	}
+
+	return ""
+}
+
+// setOwner records an ALTER ... OWNER TO.  Like PostgreSQL, a change of owner
+// moves the privileges held and granted by the old owner to the new one.
+func (d *dumpState) setOwner(o Object, owner string) {
+	obj := d.object(o)
+	if obj.changed && obj.Owner != "" {
+		var acls []ACL
+		for _, a := range obj.ACL {
+			if a.Role == obj.Owner {
+				a.Role = owner
+			}
+			if a.GrantedBy == obj.Owner {
+				a.GrantedBy = owner
+			}
+			acls = grantACL(acls, a.Role, a.GrantedBy, a.Privileges, a.GrantOptions)
+		}
+		obj.ACL = acls
+	}
+
+	obj.Owner = owner
+}
+
+// apply replays a GRANT or REVOKE statement.
+func (d *dumpState) apply(s Statement) error {
+	switch {
+	case len(s.Roles) > 0:
+		return nil
+	case s.isDefault():
+		return d.applyDefault(s)
+	}
+
+	objs := s.Objects
+	if s.AllInSchema {
+		objs = d.inSchemas(s.Objects)
+	}
+
+	for _, o := range objs {
+		obj := d.object(o)
+		owner := d.ownerOf(obj)
+		if !obj.changed {
+			obj.changed = true
+			if owner != "" {
+				obj.ACL = obj.Kind.ACLDefault(owner)
+			}
+		}
+
+		grantor := s.GrantedBy
+		for _, r := range []string{d.role, d.session, owner} {
+			if grantor == "" {
+				grantor = r
+			}
+		}
+
+		obj.ACL = change(obj.ACL, s, grantor)
+	}
+
+	return nil
+}
+
+// inSchemas returns the known objects of the kind and schemas of an ON ALL
+// ... IN SCHEMA statement.
+func (d *dumpState) inSchemas(targets []Object) []Object {
+	var objs []Object
+	for _, key := range d.objectKeys {
+		obj := d.objects[key]
+		for _, t := range targets {
+			if (obj.Kind == t.Kind || (t.Kind == KindRoutine && obj.Kind.routine())) && obj.Schema == t.Schema {
+				objs = append(objs, obj.Object)
+				break
+			}
+		}
+	}
+
+	return objs
+}
+
+// applyDefault replays an ALTER DEFAULT PRIVILEGES statement.  Without FOR
+// ROLE it applies to the current role or session user.  Entries for all
+// schemas start out as the ACLDefault of their role and entries for one
+// schema start out empty, as in pg_default_acl, where routines are stored as
+// functions.
+func (d *dumpState) applyDefault(s Statement) error {
+	role := s.DefaultFor
+	for _, r := range []string{d.role, d.session} {
+		if role == "" {
+			role = r
+		}
+	}
+
+	if role == "" {
+		return fmt.Errorf("ALTER DEFAULT PRIVILEGES without FOR ROLE needs a SET ROLE or SET SESSION AUTHORIZATION before it")
+	}
+
+	for _, o := range s.Objects {
+		kind := o.Kind
+		if kind == KindRoutine {
+			kind = KindFunction
+		}
+
+		def := DefaultACL{Role: role, Schema: o.Schema, Kind: kind}
+		key := def.Key()
+		entry, ok := d.defaults[key]
+		if !ok {
+			if def.Schema == "" {
+				def.ACL = def.Kind.ACLDefault(def.Role)
+			}
+			entry = &def
+			d.defaults[key] = entry
+			d.defaultKeys = append(d.defaultKeys, key)
+		}
+
+		entry.ACL = change(entry.ACL, s, role)
+	}
+
+	return nil
+}
+
+// dump returns the rebuilt privileges.  Column ACLs that end up empty and
+// default privileges equal to the built-in ones are dropped, as PostgreSQL
+// does not store them either.
+func (d *dumpState) dump() *Dump {
+	dump := &Dump{}
+	for _, key := range d.objectKeys {
+		obj := d.objects[key]
+		if obj.Kind == KindColumn {
+			obj.Owner = d.ownerOf(obj)
+			if len(obj.ACL) == 0 {
+				continue
+			}
+		} else if !obj.changed && obj.Owner != "" {
+			obj.ACL = obj.Kind.ACLDefault(obj.Owner)
+		}
+
+		dump.Objects = append(dump.Objects, obj.Object)
+	}
+
+	for _, key := range d.defaultKeys {
+		def := d.defaults[key]
+		if def.Schema == "" && sameACL(def.ACL, def.Kind.ACLDefault(def.Role)) || def.Schema != "" && len(def.ACL) == 0 {
+			continue
+		}
+
+		dump.DefaultACLs = append(dump.DefaultACLs, *def)
+	}
+
+	return dump
+}
+
+// change applies the privileges of s to every grantee in acls with grantor as
+// the granting role.
+func change(acls []ACL, s Statement, grantor string) []ACL {
+	for _, grantee := range s.Grantees {
+		if s.Action == Grant {
+			var options Privileges
+			if s.WithGrantOption {
+				options = s.Privileges
+			}
+			acls = grantACL(acls, grantee, grantor, s.Privileges, options)
+			continue
+		}
+
+		acls = revokeACL(acls, grantee, grantor, s.Privileges, s.WithGrantOption)
+	}
+
+	return acls
+}
+
+// grantACL adds privileges and grant options to the entry for role and
+// grantor, appending one if there is none.
+func grantACL(acls []ACL, role, grantor string, privs, options Privileges) []ACL {
+	for i, a := range acls {
+		if a.Role == role && a.GrantedBy == grantor {
+			acls[i].Privileges |= privs
+			acls[i].GrantOptions |= options
+			return acls
+		}
+	}
+
+	return append(acls, ACL{Role: role, GrantedBy: grantor, Privileges: privs, GrantOptions: options})
+}
+
+// revokeACL removes the grant options of privs from the entry for role and
+// grantor and, unless optionsOnly is set, the privileges too.  Entries left
+// without privileges are dropped.
+func revokeACL(acls []ACL, role, grantor string, privs Privileges, optionsOnly bool) []ACL {
+	kept := acls[:0]
+	for _, a := range acls {
+		if a.Role == role && a.GrantedBy == grantor {
+			a.GrantOptions &^= privs
+			if !optionsOnly {
+				a.Privileges &^= privs
+			}
+		}
+
+		if a.Privileges != NoPrivs {
+			kept = append(kept, a)
+		}
+	}
+
+	return kept
+}
+
+// sameACL reports whether a and b hold the same entries in any order.
+func sameACL(a, b []ACL) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	seen := make(map[ACL]int, len(a))
+	for _, e := range a {
+		seen[e]++
+	}
+	for _, e := range b {
+		if seen[e] == 0 {
+			return false
+		}
+		seen[e]--
+	}
+
+	return true
+}
@@ -0,0 +1,180 @@
+package acl_test
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+func TestObjectDumpSQL(t *testing.T) {
+	mustParse := func(s ...string) []acl.ACL {
+		var acls []acl.ACL
+		for _, item := range s {
+			a, err := acl.Parse(item)
+			if err != nil {
+				t.Fatalf("unable to parse %+q: %v", item, err)
+			}
+			acls = append(acls, a)
+		}
+		return acls
+	}
+
+	tests := []struct {
+		name  string
+		obj   acl.Object
+		owner string
+		want  string
+	}{
+		{
+			name:  "default",
+			obj:   acl.Object{Kind: acl.KindTable, Schema: "public", Name: "t", Owner: "app", ACL: mustParse("app=arwdDxt/app")},
+			owner: `ALTER TABLE public.t OWNER TO app`,
+		},
+		{
+			name:  "grant",
+			obj:   acl.Object{Kind: acl.KindTable, Schema: "public", Name: "accounts", Owner: "app", ACL: mustParse("app=arwdDxt/app", "ro=r/app")},
+			owner: `ALTER TABLE public.accounts OWNER TO app`,
+			want:  "GRANT SELECT ON TABLE public.accounts TO ro;\n",
+		},
+		{
+			name:  "owner privileges revoked",
+			obj:   acl.Object{Kind: acl.KindTable, Schema: "public", Name: "t", Owner: "app", ACL: mustParse("rw=arwd/app", "app=arwdxt/app")},
+			owner: `ALTER TABLE public.t OWNER TO app`,
+			want: "REVOKE ALL ON TABLE public.t FROM app;\n" +
+				"GRANT SELECT,INSERT,REFERENCES,DELETE,TRIGGER,UPDATE ON TABLE public.t TO app;\n" +
+				"GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.t TO rw;\n",
+		},
+		{
+			name:  "grant option and grantor",
+			obj:   acl.Object{Kind: acl.KindTable, Schema: "public", Name: "t", Owner: "app", ACL: mustParse("app=arwdDxt/app", "ro=r*a/app", "audit=r/ro")},
+			owner: `ALTER TABLE public.t OWNER TO app`,
+			want: "GRANT INSERT ON TABLE public.t TO ro;\n" +
+				"GRANT SELECT ON TABLE public.t TO ro WITH GRANT OPTION;\n" +
+				"SET SESSION AUTHORIZATION ro;\n" +
+				"GRANT SELECT ON TABLE public.t TO audit;\n" +
+				"RESET SESSION AUTHORIZATION;\n",
+		},
+		{
+			name:  "all with grant option",
+			obj:   acl.Object{Kind: acl.KindSequence, Schema: "public", Name: "s", Owner: "app", ACL: mustParse("app=rwU/app", "rw=r*w*U*/app")},
+			owner: `ALTER SEQUENCE public.s OWNER TO app`,
+			want:  "GRANT ALL ON SEQUENCE public.s TO rw WITH GRANT OPTION;\n",
+		},
+		{
+			name:  "quoting",
+			obj:   acl.Object{Kind: acl.KindTable, Schema: "App", Name: "user", Owner: "app", ACL: append(mustParse("app=arwdDxt/app"), acl.ACL{Role: "Read Only", Privileges: acl.Select, GrantedBy: "app"})},
+			owner: `ALTER TABLE "App"."user" OWNER TO app`,
+			want:  `GRANT SELECT ON TABLE "App"."user" TO "Read Only";` + "\n",
+		},
+		{
+			name:  "columns",
+			obj:   acl.Object{Kind: acl.KindColumn, Schema: "public", Name: "users", Column: "email", Owner: "app", ACL: mustParse("rw=rw/app", "ro=arwx/app")},
+			owner: `ALTER TABLE public.users OWNER TO app`,
+			want: "GRANT SELECT(email),UPDATE(email) ON TABLE public.users TO rw;\n" +
+				"GRANT ALL(email) ON TABLE public.users TO ro;\n",
+		},
+		{
+			name:  "function",
+			obj:   acl.Object{Kind: acl.KindFunction, Schema: "public", Name: "Touch", Signature: "a integer", Owner: "app", ACL: mustParse("app=X/app", "rw=X/app")},
+			owner: `ALTER FUNCTION public."Touch"(a integer) OWNER TO app`,
+			want: "REVOKE ALL ON FUNCTION public.\"Touch\"(a integer) FROM PUBLIC;\n" +
+				"GRANT ALL ON FUNCTION public.\"Touch\"(a integer) TO rw;\n",
+		},
+		{
+			name:  "procedure",
+			obj:   acl.Object{Kind: acl.KindProcedure, Schema: "public", Name: "archive", Signature: "d date", Owner: "app", ACL: mustParse("=X/app", "app=X/app", "ops=X/app")},
+			owner: `ALTER PROCEDURE public.archive(d date) OWNER TO app`,
+			want:  "GRANT ALL ON PROCEDURE public.archive(d date) TO ops;\n",
+		},
+		{
+			name:  "domain",
+			obj:   acl.Object{Kind: acl.KindDomain, Schema: "public", Name: "email", Owner: "app", ACL: mustParse("app=U/app")},
+			owner: `ALTER DOMAIN public.email OWNER TO app`,
+			want:  "REVOKE ALL ON TYPE public.email FROM PUBLIC;\n",
+		},
+		{
+			name:  "database",
+			obj:   acl.Object{Kind: acl.KindDatabase, Name: "ledger", Owner: "app", ACL: mustParse("=Tc/app", "app=CTc/app", "ro=c/app")},
+			owner: `ALTER DATABASE ledger OWNER TO app`,
+			want:  "GRANT CONNECT ON DATABASE ledger TO ro;\n",
+		},
+		{
+			name:  "large object",
+			obj:   acl.Object{Kind: acl.KindLargeObject, OID: 16440, Owner: "app", ACL: mustParse("app=rw/app", "ro=r/app")},
+			owner: `ALTER LARGE OBJECT 16440 OWNER TO app`,
+			want:  "GRANT SELECT ON LARGE OBJECT 16440 TO ro;\n",
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			got := test.obj.DumpSQL()
+			if got != test.want {
+				t.Fatalf("want %+q got %+q", test.want, got)
+			}
+
+			// Replaying the statements on top of the owner's built-in ACL
+			// restores the ACL.
+			d, err := acl.ReadDump(strings.NewReader(test.owner + ";\n" + got))
+			if err != nil {
+				t.Fatalf("unable to read dump: %v", err)
+			}
+
+			want := aclStrings(test.obj.ACL)
+			for _, o := range d.Objects {
+				if o.Key() == test.obj.Key() {
+					if got := aclStrings(o.ACL); !reflect.DeepEqual(got, want) {
+						t.Fatalf("bad: expected %#v to equal %#v", got, want)
+					}
+					return
+				}
+			}
+			t.Fatalf("bad: %s missing from replayed dump", test.obj.Key())
+		})
+	}
+}
+
+func TestObjectDumpSQLInitialACL(t *testing.T) {
+	// An extension that revoked EXECUTE from PUBLIC and granted it to a role
+	// of its own.
+	initial := []acl.ACL{
+		{Role: "postgres", GrantedBy: "postgres", Privileges: acl.Execute},
+		{Role: "pg_read_all_stats", GrantedBy: "postgres", Privileges: acl.Execute},
+	}
+
+	obj := acl.Object{
+		Kind:       acl.KindFunction,
+		Schema:     "public",
+		Name:       "pg_stat_statements_reset",
+		Owner:      "postgres",
+		ACL:        append([]acl.ACL{{Role: "monitor", GrantedBy: "postgres", Privileges: acl.Execute}}, initial...),
+		InitialACL: initial,
+	}
+
+	const want = "GRANT ALL ON FUNCTION public.pg_stat_statements_reset() TO monitor;\n"
+	if got := obj.DumpSQL(); got != want {
+		t.Fatalf("want %+q got %+q", want, got)
+	}
+
+	obj.ACL = initial
+	if got := obj.DumpSQL(); got != "" {
+		t.Fatalf("want %+q got %+q", "", got)
+	}
+}
+
+func aclStrings(acls []acl.ACL) []string {
+	var s []string
+	for _, a := range acls {
+		s = append(s, a.String())
+	}
+	sort.Strings(s)
+
+	return s
+}
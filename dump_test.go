@@ -0,0 +1,266 @@
+package acl_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+const testDump = `--
+-- PostgreSQL database dump
+--
+
+\restrict 8dGmNhwUk3
+
+SET statement_timeout = 0;
+SET client_encoding = 'UTF8';
+SELECT pg_catalog.set_config('search_path', '', false);
+
+CREATE SCHEMA app;
+
+ALTER SCHEMA app OWNER TO owner;
+
+CREATE FUNCTION app.touch(a integer, b text) RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  EXECUTE 'GRANT ALL ON app.accounts TO evil';
+  RETURN NEW;
+END
+$$;
+
+ALTER FUNCTION app.touch(a integer, b text) OWNER TO owner;
+
+CREATE DOMAIN app.email AS text;
+
+ALTER DOMAIN app.email OWNER TO owner;
+
+CREATE TABLE app.accounts (
+    id integer NOT NULL,
+    email app.email
+);
+
+ALTER TABLE app.accounts OWNER TO owner;
+
+CREATE SEQUENCE app.accounts_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER TABLE app.accounts_id_seq OWNER TO owner;
+
+ALTER SEQUENCE app.accounts_id_seq OWNED BY app.accounts.id;
+
+CREATE VIEW app.v AS
+ SELECT 1 AS one;
+
+ALTER TABLE app.v OWNER TO owner;
+
+CREATE SEQUENCE app.audit_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER TABLE app.audit_seq OWNER TO owner;
+
+ALTER TABLE ONLY app.accounts
+    ADD CONSTRAINT accounts_pkey PRIMARY KEY (id);
+
+SELECT pg_catalog.lo_create('16440');
+
+ALTER LARGE OBJECT 16440 OWNER TO owner;
+
+--
+-- Name: SCHEMA app; Type: ACL; Schema: -; Owner: owner
+--
+
+GRANT USAGE ON SCHEMA app TO ro;
+
+REVOKE ALL ON FUNCTION app.touch(a integer, b text) FROM PUBLIC;
+GRANT ALL ON FUNCTION app.touch(a integer, b text) TO rw;
+
+REVOKE ALL ON TYPE app.email FROM PUBLIC;
+
+GRANT SELECT ON TABLE app.accounts TO ro;
+GRANT SELECT ON TABLE app.accounts TO rw WITH GRANT OPTION;
+SET SESSION AUTHORIZATION rw;
+GRANT SELECT ON TABLE app.accounts TO audit;
+RESET SESSION AUTHORIZATION;
+
+GRANT SELECT(email),UPDATE(email) ON TABLE app.accounts TO rw;
+
+GRANT SELECT,USAGE ON SEQUENCE app.accounts_id_seq TO rw;
+
+ALTER DEFAULT PRIVILEGES FOR ROLE owner IN SCHEMA app GRANT SELECT ON TABLES TO ro;
+ALTER DEFAULT PRIVILEGES FOR ROLE owner REVOKE ALL ON FUNCTIONS FROM PUBLIC;
+ALTER DEFAULT PRIVILEGES FOR ROLE owner GRANT ALL ON TYPES TO PUBLIC;
+
+--
+-- PostgreSQL database dump complete
+--
+
+\unrestrict 8dGmNhwUk3
+`
+
+func TestReadDump(t *testing.T) {
+	tests := []struct {
+		name     string
+		dump     string
+		objects  []string
+		defaults []string
+		fail     bool
+	}{
+		{
+			name: "pg_dump",
+			dump: testDump,
+			objects: []string{
+				`schema:"app" owner=UC/owner ro=U/owner`,
+				`function:"app"."touch"(a integer, b text) owner=X/owner rw=X/owner`,
+				`domain:"app"."email" owner=U/owner`,
+				`table:"app"."accounts" owner=arwdDxt/owner ro=r/owner rw=r*/owner audit=r/rw`,
+				`sequence:"app"."accounts_id_seq" owner=rwU/owner rw=rU/owner`,
+				`table:"app"."v" owner=arwdDxt/owner`,
+				`sequence:"app"."audit_seq" owner=rwU/owner`,
+				`large_object:16440 owner=rw/owner`,
+				`column:"app"."accounts"."email" rw=rw/owner`,
+			},
+			defaults: []string{
+				`default:table:role=owner:schema=app ro=r/owner`,
+				`default:function:role=owner owner=X/owner`,
+			},
+		},
+		{
+			name: "owner change",
+			dump: `ALTER TABLE t OWNER TO a;
+GRANT SELECT ON TABLE t TO ro;
+GRANT UPDATE ON TABLE t TO ro;
+ALTER TABLE t OWNER TO b;`,
+			objects: []string{`table:"t" b=arwdDxt/b ro=rw/b`},
+		},
+		{
+			name: "revoke",
+			dump: `ALTER DATABASE db OWNER TO a;
+REVOKE CONNECT,TEMPORARY ON DATABASE db FROM PUBLIC;
+GRANT CONNECT ON DATABASE db TO ro WITH GRANT OPTION;
+REVOKE GRANT OPTION FOR CONNECT ON DATABASE db FROM ro;
+ALTER TABLE t OWNER TO a;
+GRANT SELECT(id) ON TABLE t TO ro;
+REVOKE SELECT(id) ON TABLE t FROM ro;`,
+			objects: []string{
+				`database:"db" a=CTc/a ro=c/a`,
+				`table:"t" a=arwdDxt/a`,
+			},
+		},
+		{
+			name: "set role",
+			dump: `ALTER SCHEMA s OWNER TO a;
+SET ROLE b;
+GRANT USAGE ON SCHEMA s TO c;
+RESET ROLE;
+SET SESSION AUTHORIZATION 'd';
+GRANT CREATE ON SCHEMA s TO c;
+SET SESSION AUTHORIZATION DEFAULT;
+GRANT USAGE ON SCHEMA s TO e;`,
+			objects: []string{`schema:"s" a=UC/a c=U/b c=C/d e=U/a`},
+		},
+		{
+			name: "migration",
+			dump: `ALTER PROCEDURE app.archive(date) OWNER TO a;
+ALTER FUNCTION app.balance(integer) OWNER TO a;
+GRANT EXECUTE ON ROUTINE app.archive(date) TO ops;
+REVOKE EXECUTE ON ALL ROUTINES IN SCHEMA app FROM PUBLIC;
+SET ROLE a;
+ALTER DEFAULT PRIVILEGES IN SCHEMA app GRANT SELECT ON TABLES TO ro;
+ALTER DEFAULT PRIVILEGES REVOKE EXECUTE ON ROUTINES FROM PUBLIC;`,
+			objects: []string{
+				`procedure:"app"."archive"(date) a=X/a ops=X/a`,
+				`function:"app"."balance"(integer) a=X/a`,
+			},
+			defaults: []string{
+				`default:table:role=a:schema=app ro=r/a`,
+				`default:function:role=a a=X/a`,
+			},
+		},
+		{
+			name: "sequence owner set before it is known",
+			dump: `ALTER TABLE s OWNER TO a;
+GRANT USAGE ON SEQUENCE s TO ro;`,
+			objects: []string{`sequence:"s" a=rwU/a ro=U/a`},
+		},
+		{
+			name: "copy data",
+			dump: `ALTER TABLE public.notes OWNER TO a;
+COPY public.notes (id, body) FROM stdin;
+1	it's; GRANT ALL ON TABLE public.notes TO x;
+2	"unbalanced
+\.
+
+GRANT SELECT ON TABLE public.notes TO ro;`,
+			objects: []string{`table:"public"."notes" a=arwdDxt/a ro=r/a`},
+		},
+		{
+			name: "default privileges without role",
+			dump: `ALTER DEFAULT PRIVILEGES GRANT SELECT ON TABLES TO ro;`,
+			fail: true,
+		},
+		{
+			name: "unparsable statement",
+			dump: `ALTER SCHEMA s OWNER TO a;
+GRANT SET ON PARAMETER work_mem TO ro;
+GRANT USAGE ON SCHEMA s TO ro;`,
+			objects: []string{`schema:"s" a=UC/a ro=U/a`},
+			fail:    true,
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			d, err := acl.ReadDump(strings.NewReader(test.dump))
+			if test.fail {
+				var scriptErr *acl.ScriptError
+				if !errors.As(err, &scriptErr) {
+					t.Fatalf("expected failure")
+				}
+			} else if err != nil {
+				t.Fatalf("unable to read dump: %v", err)
+			}
+
+			var objects []string
+			for _, o := range d.Objects {
+				objects = append(objects, dumpLine(o.Key(), o.ACL))
+			}
+			if !reflect.DeepEqual(objects, test.objects) {
+				t.Fatalf("bad: expected %#v to equal %#v", objects, test.objects)
+			}
+
+			var defaults []string
+			for _, def := range d.DefaultACLs {
+				defaults = append(defaults, dumpLine(def.Key(), def.ACL))
+			}
+			if !reflect.DeepEqual(defaults, test.defaults) {
+				t.Fatalf("bad: expected %#v to equal %#v", defaults, test.defaults)
+			}
+		})
+	}
+}
+
+func dumpLine(key string, acls []acl.ACL) string {
+	fields := []string{key}
+	for _, a := range acls {
+		fields = append(fields, a.String())
+	}
+
+	return strings.Join(fields, " ")
+}
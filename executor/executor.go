@@ -0,0 +1,232 @@
+// Package executor runs the statements generated by this module, such as a
+// plan, in a single transaction.
+//
+// Statements can each be wrapped in a savepoint so that one blocked by a lock
+// is retried on its own, the transaction can be given a lock_timeout so it
+// fails fast instead of queueing behind long-running work, and the result can
+// be verified against the catalog after the commit.
+package executor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sean-/postgresql-acl/catalog"
+	"github.com/sean-/postgresql-acl/plan"
+)
+
+// lockNotAvailable is the SQLSTATE raised when lock_timeout expires.
+const lockNotAvailable = "55P03"
+
+// DB is the subset of *sql.DB and *sql.Conn used by an Executor.
+type DB interface {
+	catalog.Querier
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// VerifyFunc checks the state of the database after a commit.
+type VerifyFunc func(ctx context.Context, q catalog.Querier) error
+
+// Executor runs statements against DB in one transaction.
+type Executor struct {
+	DB DB
+
+	// DryRun logs the statements instead of running them.  Before still runs
+	// and the transaction is rolled back.
+	DryRun bool
+
+	// Savepoints wraps each statement in a savepoint, so a statement that
+	// fails to get a lock is retried on its own rather than by restarting
+	// the transaction.
+	Savepoints bool
+
+	// LockTimeout sets lock_timeout for the transaction, rounded up to whole
+	// milliseconds, as a lock_timeout of 0 disables it.  Zero keeps the
+	// server's setting.
+	LockTimeout time.Duration
+
+	// Retries is the number of times a statement, or the whole transaction
+	// without savepoints, is retried after failing to get a lock.
+	Retries int
+
+	// RetryDelay is the pause before each retry.
+	RetryDelay time.Duration
+
+	// Log receives every statement as it runs and a line for each retry.
+	Log io.Writer
+
+	// Before runs in the transaction ahead of the statements.  An error
+	// aborts the transaction.
+	Before func(ctx context.Context, tx *sql.Tx) error
+
+	// Verify runs after the commit.
+	Verify VerifyFunc
+}
+
+// Run runs stmts in a transaction and then verifies the result.  The
+// transaction is rolled back on the first error that is not retried.
+func (e *Executor) Run(ctx context.Context, stmts []string) error {
+	for attempt := 0; ; attempt++ {
+		err := e.run(ctx, stmts)
+		if err == nil {
+			break
+		}
+
+		if e.Savepoints || !isLockNotAvailable(err) || attempt >= e.Retries {
+			return err
+		}
+
+		e.logf("-- lock not available, retrying transaction (%d/%d)\n", attempt+1, e.Retries)
+		if err := sleep(ctx, e.RetryDelay); err != nil {
+			return err
+		}
+	}
+
+	if e.DryRun || e.Verify == nil {
+		return nil
+	}
+
+	if err := e.Verify(ctx, e.DB); err != nil {
+		return fmt.Errorf("verification failed: %w", err)
+	}
+
+	return nil
+}
+
+func (e *Executor) run(ctx context.Context, stmts []string) (err error) {
+	tx, err := e.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if e.LockTimeout > 0 {
+		ms := (e.LockTimeout + time.Millisecond - 1) / time.Millisecond
+		stmt := fmt.Sprintf("SET LOCAL lock_timeout = '%dms'", ms)
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("unable to set lock_timeout: %w", err)
+		}
+	}
+
+	if e.Before != nil {
+		if err := e.Before(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	for i, stmt := range stmts {
+		e.logf("%s;\n", stmt)
+		if e.DryRun {
+			continue
+		}
+
+		if e.Savepoints {
+			err = e.execSavepoint(ctx, tx, fmt.Sprintf("pgacl_%d", i+1), stmt)
+		} else {
+			_, err = tx.ExecContext(ctx, stmt)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+
+	if e.DryRun {
+		return tx.Rollback()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("unable to commit: %w", err)
+	}
+
+	return nil
+}
+
+// execSavepoint runs stmt within a savepoint, rolling back to it and retrying
+// when the statement cannot get a lock.
+func (e *Executor) execSavepoint(ctx context.Context, tx *sql.Tx, name, stmt string) error {
+	savepoint := pq.QuoteIdentifier(name)
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		_, err := tx.ExecContext(ctx, stmt)
+		if err == nil {
+			break
+		}
+
+		if !isLockNotAvailable(err) || attempt >= e.Retries {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); err != nil {
+			return err
+		}
+
+		e.logf("-- lock not available, retrying statement (%d/%d)\n", attempt+1, e.Retries)
+		if err := sleep(ctx, e.RetryDelay); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint)
+	return err
+}
+
+func (e *Executor) logf(format string, args ...interface{}) {
+	if e.Log != nil {
+		fmt.Fprintf(e.Log, format, args...)
+	}
+}
+
+// Converged returns a VerifyFunc that reloads the catalog for the given
+// schemas and fails if it does not match desired for roles, i.e. if planning
+// again would produce any statements.
+func Converged(desired plan.State, roles []string, schemas ...string) VerifyFunc {
+	return func(ctx context.Context, q catalog.Querier) error {
+		c, err := catalog.Load(ctx, q, schemas...)
+		if err != nil {
+			return err
+		}
+
+		actual := plan.State{Objects: c.Objects(), DefaultACLs: c.DefaultACLs}
+		if pending := plan.Compute(desired, actual, roles); len(pending) > 0 {
+			return fmt.Errorf("%d statements still pending: %s", len(pending), strings.Join(pending.SQL(), "; "))
+		}
+
+		return nil
+	}
+}
+
+func isLockNotAvailable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == lockNotAvailable
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,181 @@
+package executor_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	acl "github.com/sean-/postgresql-acl"
+	"github.com/sean-/postgresql-acl/executor"
+	"github.com/sean-/postgresql-acl/internal/fakedb"
+	"github.com/sean-/postgresql-acl/plan"
+)
+
+const (
+	grantSelect = `GRANT SELECT ON TABLE "public"."accounts" TO "ro"`
+	grantUsage  = `GRANT USAGE ON SCHEMA "public" TO "ro"`
+)
+
+var errLock = &pq.Error{Code: "55P03", Message: "canceling statement due to lock timeout"}
+
+// statements returns the log without the catalog queries.
+func statements(log []string) []string {
+	var stmts []string
+	for _, s := range log {
+		if !strings.HasPrefix(s, "SELECT") {
+			stmts = append(stmts, s)
+		}
+	}
+
+	return stmts
+}
+
+func TestRun(t *testing.T) {
+	desired := plan.State{Objects: []acl.Object{{
+		Kind: acl.KindTable, Schema: "public", Name: "accounts", Owner: "app",
+		ACL: []acl.ACL{{Role: "ro", GrantedBy: "app", Privileges: acl.Select}},
+	}}}
+
+	tests := []struct {
+		name    string
+		exec    executor.Executor
+		results []fakedb.Result
+		log     []string
+		output  string
+		err     string
+	}{
+		{
+			name: "plain",
+			log:  []string{"BEGIN", grantSelect, grantUsage, "COMMIT"},
+		},
+		{
+			name: "savepoints and lock timeout",
+			exec: executor.Executor{Savepoints: true, LockTimeout: 500 * time.Millisecond},
+			log: []string{
+				"BEGIN",
+				"SET LOCAL lock_timeout = '500ms'",
+				`SAVEPOINT "pgacl_1"`, grantSelect, `RELEASE SAVEPOINT "pgacl_1"`,
+				`SAVEPOINT "pgacl_2"`, grantUsage, `RELEASE SAVEPOINT "pgacl_2"`,
+				"COMMIT",
+			},
+		},
+		{
+			name: "lock timeout below a millisecond",
+			exec: executor.Executor{LockTimeout: 100 * time.Microsecond},
+			log:  []string{"BEGIN", "SET LOCAL lock_timeout = '1ms'", grantSelect, grantUsage, "COMMIT"},
+		},
+		{
+			name:    "statement retried",
+			exec:    executor.Executor{Savepoints: true, Retries: 2},
+			results: []fakedb.Result{{Match: grantSelect, Err: errLock, Times: 1}},
+			log: []string{
+				"BEGIN",
+				`SAVEPOINT "pgacl_1"`, grantSelect, `ROLLBACK TO SAVEPOINT "pgacl_1"`, grantSelect, `RELEASE SAVEPOINT "pgacl_1"`,
+				`SAVEPOINT "pgacl_2"`, grantUsage, `RELEASE SAVEPOINT "pgacl_2"`,
+				"COMMIT",
+			},
+			output: "retrying statement (1/2)",
+		},
+		{
+			name:    "transaction retried",
+			exec:    executor.Executor{Retries: 1},
+			results: []fakedb.Result{{Match: grantUsage, Err: errLock, Times: 1}},
+			log: []string{
+				"BEGIN", grantSelect, grantUsage, "ROLLBACK",
+				"BEGIN", grantSelect, grantUsage, "COMMIT",
+			},
+			output: "retrying transaction (1/1)",
+		},
+		{
+			name:    "retries exhausted",
+			exec:    executor.Executor{Savepoints: true, Retries: 1},
+			results: []fakedb.Result{{Match: grantSelect, Err: errLock}},
+			log: []string{
+				"BEGIN",
+				`SAVEPOINT "pgacl_1"`, grantSelect, `ROLLBACK TO SAVEPOINT "pgacl_1"`, grantSelect,
+				"ROLLBACK",
+			},
+			err: "lock timeout",
+		},
+		{
+			name:    "other errors are not retried",
+			exec:    executor.Executor{Retries: 3},
+			results: []fakedb.Result{{Match: grantSelect, Err: errors.New("permission denied")}},
+			log:     []string{"BEGIN", grantSelect, "ROLLBACK"},
+			err:     "permission denied",
+		},
+		{
+			name:   "dry run",
+			exec:   executor.Executor{DryRun: true, Verify: executor.Converged(desired, []string{"ro"})},
+			log:    []string{"BEGIN", "ROLLBACK"},
+			output: grantSelect + ";\n" + grantUsage + ";\n",
+		},
+		{
+			name: "before fails",
+			exec: executor.Executor{Before: func(context.Context, *sql.Tx) error {
+				return errors.New("stale")
+			}},
+			log: []string{"BEGIN", "ROLLBACK"},
+			err: "stale",
+		},
+		{
+			name: "verified",
+			exec: executor.Executor{Verify: executor.Converged(desired, []string{"ro"})},
+			results: []fakedb.Result{{
+				Match: "c.relkind IN",
+				Rows:  [][]driver.Value{{int64(16384), "public", "accounts", "r", "app", "{app=arwdDxt/app,ro=r/app}"}},
+			}},
+			log: []string{"BEGIN", grantSelect, grantUsage, "COMMIT"},
+		},
+		{
+			name: "verification fails",
+			exec: executor.Executor{Verify: executor.Converged(desired, []string{"ro"})},
+			results: []fakedb.Result{{
+				Match: "c.relkind IN",
+				Rows:  [][]driver.Value{{int64(16384), "public", "accounts", "r", "app", "{app=arwdDxt/app}"}},
+			}},
+			log: []string{"BEGIN", grantSelect, grantUsage, "COMMIT"},
+			err: "verification failed: 1 statements still pending: " + grantSelect,
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			fake := fakedb.New(test.results...)
+			var out bytes.Buffer
+
+			e := test.exec
+			e.DB = fake.Open()
+			e.Log = &out
+
+			err := e.Run(context.Background(), []string{grantSelect, grantUsage})
+			switch {
+			case err != nil && test.err == "":
+				t.Fatalf("unable to run statements: %v", err)
+			case err == nil && test.err != "":
+				t.Fatalf("expected failure")
+			case err != nil && !strings.Contains(err.Error(), test.err):
+				t.Fatalf("bad: expected %q to contain %q", err, test.err)
+			}
+
+			if log := statements(fake.Log()); !reflect.DeepEqual(test.log, log) {
+				t.Fatalf("bad: expected %q to equal %q", test.log, log)
+			}
+
+			if !strings.Contains(out.String(), test.output) {
+				t.Fatalf("bad: expected %q to contain %q", out.String(), test.output)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package acl
+
+import "fmt"
+
+// ExplodedACL is one privilege of an ACL, as returned by PostgreSQL's
+// aclexplode(): the grantor, the grantee, which is empty for PUBLIC, the
+// privilege's SQL keyword and whether it is held with the grant option.
+type ExplodedACL struct {
+	Grantor       string `json:"grantor"`
+	Grantee       string `json:"grantee"`
+	PrivilegeType string `json:"privilege_type"`
+	IsGrantable   bool   `json:"is_grantable"`
+}
+
+// Explode returns one record per privilege of every entry in acls, in the
+// order of acls and, within an entry, in the order aclexplode() uses.
+func Explode(acls []ACL) []ExplodedACL {
+	var records []ExplodedACL
+	for _, a := range acls {
+		for p := range a.Privileges.All() {
+			records = append(records, ExplodedACL{
+				Grantor:       a.GrantedBy,
+				Grantee:       a.Role,
+				PrivilegeType: privilegeList(p)[0],
+				IsGrantable:   a.GetGrantOption(p),
+			})
+		}
+	}
+
+	return records
+}
+
+// Implode rebuilds an ACL list from records returned by Explode, or read
+// back from a spreadsheet or SQL table.  The records of each grantee and
+// grantor are merged into one entry, in the order the pair first appears,
+// so imploding the records of a canonical ACL list returns the same list.
+// Privilege types are matched as ParsePrivilege matches them.
+func Implode(records []ExplodedACL) ([]ACL, error) {
+	var acls []ACL
+	for i, r := range records {
+		p, err := ParsePrivilege(r.PrivilegeType)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+
+		var opt Privileges
+		if r.IsGrantable {
+			opt = p
+		}
+
+		acls = grantACL(acls, r.Grantee, r.Grantor, p, opt)
+	}
+
+	return acls, nil
+}
@@ -0,0 +1,132 @@
+package acl_test
+
+import (
+	"reflect"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+func TestExplode(t *testing.T) {
+	tests := []struct {
+		name string
+		acls []string
+		want []acl.ExplodedACL
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "table",
+			acls: []string{"app=arwdDxt/app", "ro=r*w/app"},
+			want: []acl.ExplodedACL{
+				{Grantor: "app", Grantee: "app", PrivilegeType: "INSERT"},
+				{Grantor: "app", Grantee: "app", PrivilegeType: "SELECT"},
+				{Grantor: "app", Grantee: "app", PrivilegeType: "UPDATE"},
+				{Grantor: "app", Grantee: "app", PrivilegeType: "DELETE"},
+				{Grantor: "app", Grantee: "app", PrivilegeType: "TRUNCATE"},
+				{Grantor: "app", Grantee: "app", PrivilegeType: "REFERENCES"},
+				{Grantor: "app", Grantee: "app", PrivilegeType: "TRIGGER"},
+				{Grantor: "app", Grantee: "ro", PrivilegeType: "SELECT", IsGrantable: true},
+				{Grantor: "app", Grantee: "ro", PrivilegeType: "UPDATE"},
+			},
+		},
+		{
+			name: "PUBLIC",
+			acls: []string{"=Tc/postgres", "postgres=CTc*/postgres"},
+			want: []acl.ExplodedACL{
+				{Grantor: "postgres", PrivilegeType: "TEMPORARY"},
+				{Grantor: "postgres", PrivilegeType: "CONNECT"},
+				{Grantor: "postgres", Grantee: "postgres", PrivilegeType: "CREATE"},
+				{Grantor: "postgres", Grantee: "postgres", PrivilegeType: "TEMPORARY"},
+				{Grantor: "postgres", Grantee: "postgres", PrivilegeType: "CONNECT", IsGrantable: true},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			var acls []acl.ACL
+			for _, s := range test.acls {
+				a, err := acl.Parse(s)
+				if err != nil {
+					t.Fatalf("unable to parse %+q: %v", s, err)
+				}
+				acls = append(acls, a)
+			}
+
+			got := acl.Explode(acls)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("bad: expected %#v to equal %#v", got, test.want)
+			}
+
+			imploded, err := acl.Implode(got)
+			if err != nil {
+				t.Fatalf("unable to implode: %v", err)
+			}
+
+			var strs []string
+			for _, a := range imploded {
+				strs = append(strs, a.String())
+			}
+			if !reflect.DeepEqual(strs, test.acls) {
+				t.Fatalf("bad: expected %#v to equal %#v", strs, test.acls)
+			}
+		})
+	}
+}
+
+func TestImplode(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []acl.ExplodedACL
+		want    []string
+		fail    bool
+	}{
+		{
+			name: "merged out of order",
+			records: []acl.ExplodedACL{
+				{Grantor: "app", Grantee: "ro", PrivilegeType: "update"},
+				{Grantor: "app", Grantee: "rw", PrivilegeType: "SELECT"},
+				{Grantor: "app", Grantee: "ro", PrivilegeType: "SELECT", IsGrantable: true},
+				{Grantor: "postgres", Grantee: "ro", PrivilegeType: "INSERT"},
+			},
+			want: []string{"ro=r*w/app", "rw=r/app", "ro=a/postgres"},
+		},
+		{
+			name:    "unknown privilege",
+			records: []acl.ExplodedACL{{Grantor: "app", Grantee: "ro", PrivilegeType: "READ"}},
+			fail:    true,
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			acls, err := acl.Implode(test.records)
+			switch {
+			case err != nil && !test.fail:
+				t.Fatalf("unable to implode: %v", err)
+			case err == nil && test.fail:
+				t.Fatalf("expected failure")
+			case err != nil:
+				return
+			}
+
+			var got []string
+			for _, a := range acls {
+				got = append(got, a.String())
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("bad: expected %#v to equal %#v", got, test.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,5 @@
 package acl
 
-import "fmt"
-
 // Function models the privileges of a function aclitem
 type Function struct {
 	ACL
@@ -9,8 +7,8 @@ type Function struct {
 
 // NewFunction parses an ACL object and returns a Function object.
 func NewFunction(acl ACL) (Function, error) {
-	if !validRights(acl, validFunctionPrivs) {
-		return Function{}, fmt.Errorf("invalid flags set for function (%+q), only %+q allowed", permString(acl.Privileges, acl.GrantOptions), validFunctionPrivs)
+	if err := validRights(acl, validFunctionPrivs, "function"); err != nil {
+		return Function{}, err
 	}
 
 	return Function{ACL: acl}, nil
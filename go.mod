@@ -0,0 +1,8 @@
+module github.com/sean-/postgresql-acl
+
+go 1.23
+
+require (
+	github.com/lib/pq v1.9.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,199 @@
+package acl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PrivilegeRow is a row of the information_schema privilege views:
+// role_table_grants (or table_privileges), column_privileges,
+// usage_privileges, udt_privileges and routine_privileges.  Each row grants
+// one privilege to one grantee.
+type PrivilegeRow struct {
+	Grantor string
+	Grantee string
+
+	// ObjectType is the object_type column of usage_privileges, e.g.
+	// "SEQUENCE" or "FOREIGN SERVER".  The views without that column use
+	// "TABLE" for role_table_grants, "COLUMN" for column_privileges,
+	// "ROUTINE" for routine_privileges and "TYPE" for udt_privileges.
+	ObjectType string
+
+	// Schema and Name are the table_, object_, udt_ or routine_ schema and
+	// name columns of the view.  Foreign data wrappers and servers have an
+	// empty schema.
+	Schema string
+	Name   string
+
+	// Column is the column_name of column_privileges.
+	Column string
+
+	// SpecificName is the specific_name of routine_privileges, the routine
+	// name followed by its OID, which tells overloaded functions apart.
+	SpecificName string
+
+	PrivilegeType string
+
+	// IsGrantable is "YES" or "NO".
+	IsGrantable string
+}
+
+// privilegeRowTypes maps the object types of PrivilegeRow to object kinds.
+var privilegeRowTypes = map[string]ObjectKind{
+	"COLUMN":               KindColumn,
+	"DOMAIN":               KindDomain,
+	"FOREIGN DATA WRAPPER": KindForeignDataWrapper,
+	"FOREIGN SERVER":       KindForeignServer,
+	"ROUTINE":              KindFunction,
+	"SEQUENCE":             KindSequence,
+	"TABLE":                KindTable,
+	"TYPE":                 KindType,
+}
+
+// FromPrivilegeRows converts information_schema privilege rows into objects
+// and their ACL lists, in the order the objects first appear.  The
+// privileges each grantee holds from the same grantor are merged into one
+// ACL, and the grantee PUBLIC becomes the empty role.
+//
+// The views do not show owners or function signatures, so the objects have
+// neither, and functions carry the OID from their specific name instead.
+// Rows for collations, which have no privileges in PostgreSQL, are skipped.
+// Sequences only carry USAGE, as usage_privileges shows no other privilege
+// and table_privileges leaves sequences out, so their SELECT and UPDATE
+// privileges are lost.  Note that the views only show privileges granted to
+// or by the current user's roles and PUBLIC.
+func FromPrivilegeRows(rows []PrivilegeRow) ([]Object, error) {
+	var objs []Object
+	index := make(map[string]int)
+	for _, r := range rows {
+		if r.ObjectType == "COLLATION" {
+			continue
+		}
+
+		kind, ok := privilegeRowTypes[r.ObjectType]
+		if !ok {
+			return nil, fmt.Errorf("unsupported object type %+q", r.ObjectType)
+		}
+
+		obj := Object{Kind: kind, Schema: r.Schema, Name: r.Name, Column: r.Column}
+		if kind == KindFunction && r.SpecificName != "" {
+			i := strings.LastIndexByte(r.SpecificName, '_')
+			oid, err := strconv.ParseUint(r.SpecificName[i+1:], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid specific name %+q", r.SpecificName)
+			}
+			obj.OID = uint32(oid)
+		}
+
+		priv, err := ParsePrivilege(r.PrivilegeType)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", obj.Key(), err)
+		}
+
+		var opt Privileges
+		switch r.IsGrantable {
+		case "YES":
+			opt = priv
+		case "NO":
+		default:
+			return nil, fmt.Errorf("%s: invalid is_grantable %+q", obj.Key(), r.IsGrantable)
+		}
+
+		key := obj.Key()
+		if kind == KindFunction {
+			key += strconv.FormatUint(uint64(obj.OID), 10)
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(objs)
+			index[key] = i
+			objs = append(objs, obj)
+		}
+
+		role, grantor := privilegeRowRole(r.Grantee), privilegeRowRole(r.Grantor)
+		if err := kind.Validate(ACL{Role: role, GrantedBy: grantor, Privileges: priv, GrantOptions: opt}); err != nil {
+			return nil, fmt.Errorf("%s: %w", obj.Key(), err)
+		}
+
+		objs[i].ACL = grantACL(objs[i].ACL, role, grantor, priv, opt)
+	}
+
+	return objs, nil
+}
+
+// PrivilegeRows returns the information_schema rows that show the object's
+// ACL, one per privilege and grantee, in the order of the ACL list and of
+// Privileges.Names.  Only the kinds that the views cover are supported.
+// Procedures are shown as routines, so FromPrivilegeRows reads them back as
+// functions, and sequences only have rows for USAGE, the one privilege the
+// views show for them.
+func (o Object) PrivilegeRows() ([]PrivilegeRow, error) {
+	var objectType string
+	for t, kind := range privilegeRowTypes {
+		if kind == o.Kind || (o.Kind == KindProcedure && kind == KindFunction) {
+			objectType = t
+		}
+	}
+
+	if objectType == "" {
+		return nil, fmt.Errorf("%s: %s privileges are not shown in information_schema", o.Key(), o.Kind)
+	}
+
+	var specificName string
+	if o.Kind.routine() && o.OID != 0 {
+		specificName = o.Name + "_" + strconv.FormatUint(uint64(o.OID), 10)
+	}
+
+	shown := o.Kind.ValidPrivileges()
+	if o.Kind == KindSequence {
+		shown = Usage
+	}
+
+	var rows []PrivilegeRow
+	for _, a := range o.ACL {
+		for _, n := range privilegeNames {
+			if a.Privileges&shown&n.priv == 0 {
+				continue
+			}
+
+			grantable := "NO"
+			if a.GrantOptions&n.priv != 0 {
+				grantable = "YES"
+			}
+
+			rows = append(rows, PrivilegeRow{
+				Grantor:       privilegeRowGrantee(a.GrantedBy),
+				Grantee:       privilegeRowGrantee(a.Role),
+				ObjectType:    objectType,
+				Schema:        o.Schema,
+				Name:          o.Name,
+				Column:        o.Column,
+				SpecificName:  specificName,
+				PrivilegeType: n.name,
+				IsGrantable:   grantable,
+			})
+		}
+	}
+
+	return rows, nil
+}
+
+// privilegeRowRole returns the role of an information_schema grantee.
+func privilegeRowRole(grantee string) string {
+	if grantee == "PUBLIC" {
+		return ""
+	}
+
+	return grantee
+}
+
+// privilegeRowGrantee returns the information_schema grantee of a role.
+func privilegeRowGrantee(role string) string {
+	if role == "" {
+		return "PUBLIC"
+	}
+
+	return role
+}
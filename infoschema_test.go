@@ -0,0 +1,138 @@
+package acl_test
+
+import (
+	"reflect"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+func TestFromPrivilegeRows(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []acl.PrivilegeRow
+		want []acl.Object
+		fail bool
+	}{
+		{
+			name: "table",
+			rows: []acl.PrivilegeRow{
+				{Grantor: "app", Grantee: "ro", ObjectType: "TABLE", Schema: "public", Name: "accounts", PrivilegeType: "SELECT", IsGrantable: "YES"},
+				{Grantor: "app", Grantee: "rw", ObjectType: "TABLE", Schema: "public", Name: "accounts", PrivilegeType: "INSERT", IsGrantable: "NO"},
+				{Grantor: "app", Grantee: "rw", ObjectType: "TABLE", Schema: "public", Name: "accounts", PrivilegeType: "UPDATE", IsGrantable: "NO"},
+				{Grantor: "app", Grantee: "PUBLIC", ObjectType: "TABLE", Schema: "public", Name: "ledger", PrivilegeType: "SELECT", IsGrantable: "NO"},
+			},
+			want: []acl.Object{
+				{Kind: acl.KindTable, Schema: "public", Name: "accounts", ACL: []acl.ACL{
+					{Role: "ro", GrantedBy: "app", Privileges: acl.Select, GrantOptions: acl.Select},
+					{Role: "rw", GrantedBy: "app", Privileges: acl.Insert | acl.Update},
+				}},
+				{Kind: acl.KindTable, Schema: "public", Name: "ledger", ACL: []acl.ACL{
+					{GrantedBy: "app", Privileges: acl.Select},
+				}},
+			},
+		},
+		{
+			name: "columns, usage and routines",
+			rows: []acl.PrivilegeRow{
+				{Grantor: "app", Grantee: "ro", ObjectType: "COLUMN", Schema: "public", Name: "accounts", Column: "email", PrivilegeType: "SELECT", IsGrantable: "NO"},
+				{Grantor: "app", Grantee: "ro", ObjectType: "SEQUENCE", Schema: "public", Name: "accounts_id_seq", PrivilegeType: "USAGE", IsGrantable: "NO"},
+				{Grantor: "postgres", Grantee: "PUBLIC", ObjectType: "COLLATION", Schema: "pg_catalog", Name: "C", PrivilegeType: "USAGE", IsGrantable: "NO"},
+				{Grantor: "postgres", Grantee: "app", ObjectType: "FOREIGN SERVER", Name: "remote", PrivilegeType: "USAGE", IsGrantable: "NO"},
+				{Grantor: "app", Grantee: "ro", ObjectType: "ROUTINE", Schema: "public", Name: "balance", SpecificName: "balance_16400", PrivilegeType: "EXECUTE", IsGrantable: "NO"},
+				{Grantor: "app", Grantee: "ro", ObjectType: "ROUTINE", Schema: "public", Name: "balance", SpecificName: "balance_16401", PrivilegeType: "EXECUTE", IsGrantable: "NO"},
+			},
+			want: []acl.Object{
+				{Kind: acl.KindColumn, Schema: "public", Name: "accounts", Column: "email", ACL: []acl.ACL{{Role: "ro", GrantedBy: "app", Privileges: acl.Select}}},
+				{Kind: acl.KindSequence, Schema: "public", Name: "accounts_id_seq", ACL: []acl.ACL{{Role: "ro", GrantedBy: "app", Privileges: acl.Usage}}},
+				{Kind: acl.KindForeignServer, Name: "remote", ACL: []acl.ACL{{Role: "app", GrantedBy: "postgres", Privileges: acl.Usage}}},
+				{Kind: acl.KindFunction, Schema: "public", Name: "balance", OID: 16400, ACL: []acl.ACL{{Role: "ro", GrantedBy: "app", Privileges: acl.Execute}}},
+				{Kind: acl.KindFunction, Schema: "public", Name: "balance", OID: 16401, ACL: []acl.ACL{{Role: "ro", GrantedBy: "app", Privileges: acl.Execute}}},
+			},
+		},
+		{
+			name: "invalid privilege",
+			rows: []acl.PrivilegeRow{{Grantor: "app", Grantee: "ro", ObjectType: "SEQUENCE", Schema: "public", Name: "s", PrivilegeType: "INSERT", IsGrantable: "NO"}},
+			fail: true,
+		},
+		{
+			name: "invalid is_grantable",
+			rows: []acl.PrivilegeRow{{Grantor: "app", Grantee: "ro", ObjectType: "TABLE", Schema: "public", Name: "t", PrivilegeType: "SELECT", IsGrantable: "maybe"}},
+			fail: true,
+		},
+		{
+			name: "unsupported object type",
+			rows: []acl.PrivilegeRow{{Grantor: "app", Grantee: "ro", ObjectType: "CHARACTER SET", Name: "UTF8", PrivilegeType: "USAGE", IsGrantable: "NO"}},
+			fail: true,
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			got, err := acl.FromPrivilegeRows(test.rows)
+			switch {
+			case err != nil && !test.fail:
+				t.Fatalf("unable to convert rows: %v", err)
+			case err == nil && test.fail:
+				t.Fatalf("expected failure")
+			case err != nil:
+				return
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("bad: expected %#v to equal %#v", got, test.want)
+			}
+
+			// Converting back yields the rows, less those for collations.
+			var rows []acl.PrivilegeRow
+			for _, obj := range got {
+				r, err := obj.PrivilegeRows()
+				if err != nil {
+					t.Fatalf("unable to convert %s: %v", obj.Key(), err)
+				}
+				rows = append(rows, r...)
+			}
+
+			var want []acl.PrivilegeRow
+			for _, r := range test.rows {
+				if r.ObjectType != "COLLATION" {
+					want = append(want, r)
+				}
+			}
+
+			if !reflect.DeepEqual(rows, want) {
+				t.Fatalf("bad: expected %#v to equal %#v", rows, want)
+			}
+		})
+	}
+}
+
+func TestObjectPrivilegeRowsSequence(t *testing.T) {
+	obj := acl.Object{Kind: acl.KindSequence, Schema: "public", Name: "ids", ACL: []acl.ACL{
+		{Role: "rw", GrantedBy: "app", Privileges: acl.Select | acl.Update | acl.Usage, GrantOptions: acl.Usage},
+		{Role: "ro", GrantedBy: "app", Privileges: acl.Select},
+	}}
+
+	rows, err := obj.PrivilegeRows()
+	if err != nil {
+		t.Fatalf("unable to convert %s: %v", obj.Key(), err)
+	}
+
+	want := []acl.PrivilegeRow{
+		{Grantor: "app", Grantee: "rw", ObjectType: "SEQUENCE", Schema: "public", Name: "ids", PrivilegeType: "USAGE", IsGrantable: "YES"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("bad: expected %#v to equal %#v", rows, want)
+	}
+}
+
+func TestObjectPrivilegeRowsUnsupported(t *testing.T) {
+	obj := acl.Object{Kind: acl.KindSchema, Name: "public", ACL: []acl.ACL{{Role: "ro", GrantedBy: "postgres", Privileges: acl.Usage}}}
+	if _, err := obj.PrivilegeRows(); err == nil {
+		t.Fatalf("expected failure")
+	}
+}
@@ -0,0 +1,212 @@
+// Package fakedb is a minimal database/sql driver that answers queries from
+// canned results.  It exists so the packages that talk to PostgreSQL can be
+// tested without a running server.
+package fakedb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Result is a canned response.  A query or statement is answered by the first
+// Result whose Match is a substring of its SQL text.
+type Result struct {
+	Match   string
+	Columns []string
+	Rows    [][]driver.Value
+	Err     error
+
+	// Times limits the Result to its first Times matches, after which it is
+	// skipped.  Zero means no limit.
+	Times int
+}
+
+// DB records every statement it is sent and answers from its Results.
+type DB struct {
+	mu      sync.Mutex
+	results []Result
+	used    []int
+	log     []string
+	args    [][]driver.Value
+}
+
+// New returns a DB that answers with the given results.
+func New(results ...Result) *DB {
+	return &DB{results: results, used: make([]int, len(results))}
+}
+
+// Open returns a *sql.DB backed by d.
+func (d *DB) Open() *sql.DB {
+	return sql.OpenDB(connector{d})
+}
+
+// Log returns the SQL text of every query, statement and transaction control
+// command received so far.  Transaction control is logged as BEGIN, COMMIT
+// and ROLLBACK.
+func (d *DB) Log() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]string(nil), d.log...)
+}
+
+// Args returns the arguments of the most recent query or statement matching
+// the given substring.
+func (d *DB) Args(match string) []driver.Value {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i := len(d.log) - 1; i >= 0; i-- {
+		if strings.Contains(d.log[i], match) {
+			return d.args[i]
+		}
+	}
+
+	return nil
+}
+
+func (d *DB) record(query string, args []driver.NamedValue) Result {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	d.log = append(d.log, query)
+	d.args = append(d.args, values)
+
+	for i, r := range d.results {
+		if !strings.Contains(query, r.Match) {
+			continue
+		}
+
+		if r.Times > 0 && d.used[i] >= r.Times {
+			continue
+		}
+		d.used[i]++
+
+		return r
+	}
+
+	return Result{}
+}
+
+type connector struct {
+	db *DB
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{db: c.db}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return drv{}
+}
+
+type drv struct{}
+
+func (drv) Open(string) (driver.Conn, error) {
+	return nil, fmt.Errorf("fakedb: use DB.Open")
+}
+
+type conn struct {
+	db *DB
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fakedb: prepared statements are not supported")
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	if r := c.db.record("BEGIN", nil); r.Err != nil {
+		return nil, r.Err
+	}
+
+	return tx{c.db}, nil
+}
+
+func (c *conn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	r := c.db.record(query, args)
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	return &rows{columns: r.Columns, rows: r.Rows}, nil
+}
+
+func (c *conn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	r := c.db.record(query, args)
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	return driver.RowsAffected(0), nil
+}
+
+// CheckNamedValue accepts every argument as-is so callers can pass values
+// such as pq.Array without a conversion step.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if v, ok := nv.Value.(driver.Valuer); ok {
+		var err error
+		nv.Value, err = v.Value()
+		return err
+	}
+
+	return nil
+}
+
+type tx struct {
+	db *DB
+}
+
+func (t tx) Commit() error {
+	return t.db.record("COMMIT", nil).Err
+}
+
+func (t tx) Rollback() error {
+	return t.db.record("ROLLBACK", nil).Err
+}
+
+type rows struct {
+	columns []string
+	rows    [][]driver.Value
+	pos     int
+}
+
+func (r *rows) Columns() []string {
+	if r.columns == nil && len(r.rows) > 0 {
+		return make([]string, len(r.rows[0]))
+	}
+
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+
+	copy(dest, r.rows[r.pos])
+	r.pos++
+
+	return nil
+}
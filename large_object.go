@@ -1,7 +1,5 @@
 package acl
 
-import "fmt"
-
 // LargeObject models the privileges of a large object aclitem
 type LargeObject struct {
 	ACL
@@ -9,8 +7,8 @@ type LargeObject struct {
 
 // NewLargeObject parses an ACL object and returns a LargeObject object.
 func NewLargeObject(acl ACL) (LargeObject, error) {
-	if !validRights(acl, validLargeObjectPrivs) {
-		return LargeObject{}, fmt.Errorf("invalid flags set for large object (%+q), only %+q allowed", permString(acl.Privileges, acl.GrantOptions), validLargeObjectPrivs)
+	if err := validRights(acl, validLargeObjectPrivs, "large object"); err != nil {
+		return LargeObject{}, err
 	}
 
 	return LargeObject{ACL: acl}, nil
@@ -0,0 +1,178 @@
+package acl
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokQuoted
+	tokNumber
+	tokString
+	tokPunct
+)
+
+// token is a lexical token of a SQL script.  value is the lower-cased text
+// of a word, the unescaped name of a quoted identifier and the raw text of
+// everything else.
+type token struct {
+	kind  tokenKind
+	text  string
+	value string
+	line  int
+	col   int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of statement"
+	}
+
+	return fmt.Sprintf("%+q", t.text)
+}
+
+// lex splits src into tokens, dropping whitespace and comments.  String
+// literals, including escape and dollar-quoted strings, are kept whole so
+// semicolons within them do not end a statement.
+func lex(src string) ([]token, error) {
+	var toks []token
+	line, col := 1, 1
+	advance := func(n int) {
+		for _, r := range src[:n] {
+			if r == '\n' {
+				line, col = line+1, 1
+			} else {
+				col++
+			}
+		}
+		src = src[n:]
+	}
+
+	for len(src) > 0 {
+		c := src[0]
+		startLine, startCol := line, col
+		emit := func(kind tokenKind, n int, value string) {
+			toks = append(toks, token{kind: kind, text: src[:n], value: value, line: startLine, col: startCol})
+			advance(n)
+		}
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			advance(1)
+		case strings.HasPrefix(src, "--"):
+			n := strings.IndexByte(src, '\n')
+			if n == -1 {
+				n = len(src)
+			}
+			advance(n)
+		case strings.HasPrefix(src, "/*"):
+			depth, n := 0, 0
+			for n < len(src) {
+				switch {
+				case strings.HasPrefix(src[n:], "/*"):
+					depth, n = depth+1, n+2
+				case strings.HasPrefix(src[n:], "*/"):
+					depth, n = depth-1, n+2
+				default:
+					n++
+				}
+				if depth == 0 {
+					break
+				}
+			}
+			if depth != 0 {
+				return nil, fmt.Errorf("line %d, column %d: unterminated comment", startLine, startCol)
+			}
+			advance(n)
+		case c == '"':
+			n, value, ok := quoted(src, '"', false)
+			if !ok {
+				return nil, fmt.Errorf("line %d, column %d: unterminated quoted identifier", startLine, startCol)
+			}
+			emit(tokQuoted, n, value)
+		case c == '\'' || ((c == 'E' || c == 'e') && len(src) > 1 && src[1] == '\''):
+			prefix := 0
+			if c != '\'' {
+				prefix = 1
+			}
+			n, _, ok := quoted(src[prefix:], '\'', prefix == 1)
+			if !ok {
+				return nil, fmt.Errorf("line %d, column %d: unterminated string", startLine, startCol)
+			}
+			emit(tokString, prefix+n, "")
+		case c == '$' && dollarTag(src) != "":
+			tag := dollarTag(src)
+			end := strings.Index(src[len(tag):], tag)
+			if end == -1 {
+				return nil, fmt.Errorf("line %d, column %d: unterminated dollar-quoted string", startLine, startCol)
+			}
+			emit(tokString, len(tag)+end+len(tag), "")
+		case isWordStart(c):
+			n := 1
+			for n < len(src) && (isWordStart(src[n]) || isDigit(src[n]) || src[n] == '$') {
+				n++
+			}
+			emit(tokWord, n, strings.ToLower(src[:n]))
+		case isDigit(c):
+			n := 1
+			for n < len(src) && (isDigit(src[n]) || src[n] == '.') {
+				n++
+			}
+			emit(tokNumber, n, src[:n])
+		default:
+			emit(tokPunct, 1, src[:1])
+		}
+	}
+
+	return toks, nil
+}
+
+// quoted returns the length and unescaped contents of the quoted text at the
+// start of src.  Doubled quotes are escapes, as are backslashes if
+// backslash is set.
+func quoted(src string, q byte, backslash bool) (int, string, bool) {
+	var b strings.Builder
+	for n := 1; n < len(src); n++ {
+		switch {
+		case backslash && src[n] == '\\' && n+1 < len(src):
+			n++
+			b.WriteByte(src[n])
+		case src[n] == q && n+1 < len(src) && src[n+1] == q:
+			n++
+			b.WriteByte(q)
+		case src[n] == q:
+			return n + 1, b.String(), true
+		default:
+			b.WriteByte(src[n])
+		}
+	}
+
+	return 0, "", false
+}
+
+// dollarTag returns the opening tag of a dollar-quoted string at the start of
+// src, e.g. "$$" or "$body$", or "" if there is none.
+func dollarTag(src string) string {
+	n := 1
+	for n < len(src) && (isWordStart(src[n]) || (n > 1 && isDigit(src[n]))) {
+		n++
+	}
+
+	if n < len(src) && src[n] == '$' {
+		return src[:n+1]
+	}
+
+	return ""
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
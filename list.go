@@ -0,0 +1,131 @@
+package acl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// List is an ACL list, the equivalent of an aclitem[].  A List returned by
+// NewList or ParseList is canonical: it holds at most one entry per grantee
+// and grantor, no entries without privileges, and is sorted.
+type List []ACL
+
+// NewList returns the canonical list of acls.  As PostgreSQL does when it
+// updates an ACL, entries for the same grantee and grantor are merged by
+// combining their privileges and grant options, and entries left without
+// privileges are dropped.  Entries are sorted by grantee and then grantor,
+// the order PostgreSQL's aclitem comparison uses with role names in place of
+// OIDs, so PUBLIC comes first.
+func NewList(acls ...ACL) List {
+	l := make(List, 0, len(acls))
+	for _, a := range acls {
+		if a.Privileges == NoPrivs {
+			continue
+		}
+		l = grantACL(l, a.Role, a.GrantedBy, a.Privileges, a.GrantOptions)
+	}
+
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Role != l[j].Role {
+			return l[i].Role < l[j].Role
+		}
+		return l[i].GrantedBy < l[j].GrantedBy
+	})
+
+	return l
+}
+
+// ParseList parses an aclitem[] literal such as
+// "{postgres=arwdDxt/postgres,=r/postgres}" with Parse and returns the
+// canonical list of its entries.
+func ParseList(s string) (List, error) {
+	var items pq.StringArray
+	if err := items.Scan([]byte(s)); err != nil {
+		return nil, fmt.Errorf("invalid aclitem[] literal %+q: %w", s, err)
+	}
+
+	acls := make([]ACL, 0, len(items))
+	for _, item := range items {
+		a, err := Parse(item)
+		if err != nil {
+			return nil, err
+		}
+		acls = append(acls, a)
+	}
+
+	return NewList(acls...), nil
+}
+
+// String returns the list as an aclitem[] literal.  Items are quoted as
+// PostgreSQL's array output quotes them.
+func (l List) String() string {
+	b := new(strings.Builder)
+	b.WriteByte('{')
+	for i, a := range l {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		item := a.String()
+		if !strings.ContainsAny(item, "{},\"\\ \t\n\r\v\f") && !strings.EqualFold(item, "NULL") {
+			b.WriteString(item)
+			continue
+		}
+
+		b.WriteByte('"')
+		for _, c := range []byte(item) {
+			if c == '"' || c == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(c)
+		}
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+
+	return b.String()
+}
+
+// ForRole returns the entries granted to role, in order.  The empty role
+// stands for PUBLIC.
+func (l List) ForRole(role string) List {
+	var found List
+	for _, a := range l {
+		if a.Role == role {
+			found = append(found, a)
+		}
+	}
+
+	return found
+}
+
+// WithPrivilege returns the entries that hold every privilege in privs, in
+// order.
+func (l List) WithPrivilege(privs Privileges) List {
+	var found List
+	for _, a := range l {
+		if a.Privileges&privs == privs {
+			found = append(found, a)
+		}
+	}
+
+	return found
+}
+
+// Grantors returns the distinct grantors of the entries, sorted.
+func (l List) Grantors() []string {
+	seen := make(map[string]bool)
+	var grantors []string
+	for _, a := range l {
+		if !seen[a.GrantedBy] {
+			seen[a.GrantedBy] = true
+			grantors = append(grantors, a.GrantedBy)
+		}
+	}
+
+	sort.Strings(grantors)
+	return grantors
+}
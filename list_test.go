@@ -0,0 +1,115 @@
+package acl_test
+
+import (
+	"reflect"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		fail  bool
+	}{
+		{
+			name:  "empty",
+			input: "{}",
+			want:  "{}",
+		},
+		{
+			name:  "canonical",
+			input: "{=r/postgres,postgres=arwdDxt/postgres}",
+			want:  "{=r/postgres,postgres=arwdDxt/postgres}",
+		},
+		{
+			name:  "sorted",
+			input: "{postgres=arwdDxt/postgres,ro=r/postgres,=r/postgres,ro=r/app}",
+			want:  "{=r/postgres,postgres=arwdDxt/postgres,ro=r/app,ro=r/postgres}",
+		},
+		{
+			name:  "duplicates merged",
+			input: "{ro=r/app,rw=a/app,ro=w*/app}",
+			want:  "{ro=rw*/app,rw=a/app}",
+		},
+		{
+			name:  "empty entries dropped",
+			input: "{ro=/app,rw=a/app}",
+			want:  "{rw=a/app}",
+		},
+		{
+			name:  "quoting",
+			input: `{"\"my role\"=r/app",rw=a/app}`,
+			want:  `{"\"my role\"=r/app",rw=a/app}`,
+		},
+		{
+			name:  "invalid literal",
+			input: "{ro=r/app",
+			fail:  true,
+		},
+		{
+			name:  "invalid aclitem",
+			input: "{ro=q/app}",
+			fail:  true,
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			l, err := acl.ParseList(test.input)
+			switch {
+			case err != nil && !test.fail:
+				t.Fatalf("unable to parse %+q: %v", test.input, err)
+			case err == nil && test.fail:
+				t.Fatalf("expected failure")
+			case err != nil:
+				return
+			}
+
+			if got := l.String(); got != test.want {
+				t.Fatalf("want %+q got %+q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestListQueries(t *testing.T) {
+	l, err := acl.ParseList("{=r/app,app=arwdDxt/app,ro=r/app,ro=r/postgres,rw=arwd/app}")
+	if err != nil {
+		t.Fatalf("unable to parse list: %v", err)
+	}
+
+	strs := func(l acl.List) []string {
+		var s []string
+		for _, a := range l {
+			s = append(s, a.String())
+		}
+		return s
+	}
+
+	if got, want := strs(l.ForRole("ro")), []string{"ro=r/app", "ro=r/postgres"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("bad: expected %#v to equal %#v", got, want)
+	}
+
+	if got, want := strs(l.ForRole("")), []string{"=r/app"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("bad: expected %#v to equal %#v", got, want)
+	}
+
+	if got, want := strs(l.WithPrivilege(acl.Insert|acl.Delete)), []string{"app=arwdDxt/app", "rw=arwd/app"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("bad: expected %#v to equal %#v", got, want)
+	}
+
+	if got := l.WithPrivilege(acl.Execute); got != nil {
+		t.Fatalf("bad: expected no entries, got %v", got)
+	}
+
+	if got, want := l.Grantors(), []string{"app", "postgres"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("bad: expected %#v to equal %#v", got, want)
+	}
+}
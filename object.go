@@ -0,0 +1,223 @@
+package acl
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+// ObjectKind identifies the type of a securable object.
+type ObjectKind string
+
+// The object kinds that carry an ACL.  Views, materialized views, foreign
+// tables and partitioned tables use KindTable, and aggregates and window
+// functions use KindFunction.  KindRoutine only appears in statements written
+// with ON ROUTINE, which applies to functions and procedures alike.
+const (
+	KindColumn             ObjectKind = "column"
+	KindDatabase           ObjectKind = "database"
+	KindDomain             ObjectKind = "domain"
+	KindForeignDataWrapper ObjectKind = "foreign_data_wrapper"
+	KindForeignServer      ObjectKind = "foreign_server"
+	KindFunction           ObjectKind = "function"
+	KindLanguage           ObjectKind = "language"
+	KindLargeObject        ObjectKind = "large_object"
+	KindProcedure          ObjectKind = "procedure"
+	KindRoutine            ObjectKind = "routine"
+	KindSchema             ObjectKind = "schema"
+	KindSequence           ObjectKind = "sequence"
+	KindTable              ObjectKind = "table"
+	KindTablespace         ObjectKind = "tablespace"
+	KindType               ObjectKind = "type"
+)
+
+// objectKinds describes each ObjectKind: the SQL keyword used in the ON clause
+// of GRANT and REVOKE, the privileges valid for the kind, and the typed
+// constructor that validates an ACL for it.
+var objectKinds = map[ObjectKind]struct {
+	keyword  string
+	valid    Privileges
+	validate func(ACL) error
+}{
+	KindColumn:             {"TABLE", validColumnPrivs, func(a ACL) error { _, err := NewColumn(a); return err }},
+	KindDatabase:           {"DATABASE", validDatabasePrivs, func(a ACL) error { _, err := NewDatabase(a); return err }},
+	KindDomain:             {"DOMAIN", validDomainPrivs, func(a ACL) error { _, err := NewDomain(a); return err }},
+	KindForeignDataWrapper: {"FOREIGN DATA WRAPPER", validForeignDataWrapperPrivs, func(a ACL) error { _, err := NewForeignDataWrapper(a); return err }},
+	KindForeignServer:      {"FOREIGN SERVER", validForeignServerPrivs, func(a ACL) error { _, err := NewForeignServer(a); return err }},
+	KindFunction:           {"FUNCTION", validFunctionPrivs, func(a ACL) error { _, err := NewFunction(a); return err }},
+	KindLanguage:           {"LANGUAGE", validLanguagePrivs, func(a ACL) error { _, err := NewLanguage(a); return err }},
+	KindLargeObject:        {"LARGE OBJECT", validLargeObjectPrivs, func(a ACL) error { _, err := NewLargeObject(a); return err }},
+	KindProcedure:          {"PROCEDURE", validFunctionPrivs, func(a ACL) error { _, err := NewFunction(a); return err }},
+	KindRoutine:            {"ROUTINE", validFunctionPrivs, func(a ACL) error { _, err := NewFunction(a); return err }},
+	KindSchema:             {"SCHEMA", validSchemaPrivs, func(a ACL) error { _, err := NewSchema(a); return err }},
+	KindSequence:           {"SEQUENCE", validSequencePrivs, func(a ACL) error { _, err := NewSequence(a); return err }},
+	KindTable:              {"TABLE", validTablePrivs, func(a ACL) error { _, err := NewTable(a); return err }},
+	KindTablespace:         {"TABLESPACE", validTablespacePrivs, func(a ACL) error { _, err := NewTablespace(a); return err }},
+	KindType:               {"TYPE", validTypePrivs, func(a ACL) error { _, err := NewType(a); return err }},
+}
+
+// ParseObjectKind returns the ObjectKind named by s.
+func ParseObjectKind(s string) (ObjectKind, error) {
+	kind := ObjectKind(s)
+	if _, ok := objectKinds[kind]; !ok {
+		return "", fmt.Errorf("unknown object kind %+q", s)
+	}
+
+	return kind, nil
+}
+
+// routine returns true for the kinds stored in pg_proc, whose objects are
+// identified by their argument types as well as their name.
+func (k ObjectKind) routine() bool {
+	return k == KindFunction || k == KindProcedure || k == KindRoutine
+}
+
+// ValidPrivileges returns the privileges that may be granted on objects of
+// this kind, or NoPrivs if the kind is unknown.
+func (k ObjectKind) ValidPrivileges() Privileges {
+	return objectKinds[k].valid
+}
+
+// Validate checks acl against the typed constructor for the kind, e.g.
+// NewTable for KindTable.
+func (k ObjectKind) Validate(acl ACL) error {
+	desc, ok := objectKinds[k]
+	if !ok {
+		return fmt.Errorf("unknown object kind %+q", string(k))
+	}
+
+	return desc.validate(acl)
+}
+
+// ACLDefault returns the built-in ACL of an object of this kind owned by
+// owner, as PostgreSQL's acldefault() does for objects whose ACL is NULL: the
+// owner holds every privilege, and PUBLIC holds CONNECT and TEMPORARY on
+// databases, EXECUTE on functions and procedures and USAGE on languages,
+// types and domains.
+// Columns have no built-in privileges, and an empty owner is left out.
+func (k ObjectKind) ACLDefault(owner string) []ACL {
+	var acls []ACL
+	if public := publicDefaults[k]; public != NoPrivs {
+		acls = append(acls, ACL{Privileges: public, GrantedBy: owner})
+	}
+
+	if owner != "" && k != KindColumn && k.ValidPrivileges() != NoPrivs {
+		acls = append(acls, ACL{Privileges: k.ValidPrivileges(), Role: owner, GrantedBy: owner})
+	}
+
+	return acls
+}
+
+// publicDefaults are the privileges acldefault() grants to PUBLIC.
+var publicDefaults = map[ObjectKind]Privileges{
+	KindDatabase:  Temporary | Connect,
+	KindDomain:    Usage,
+	KindFunction:  Execute,
+	KindLanguage:  Usage,
+	KindProcedure: Execute,
+	KindRoutine:   Execute,
+	KindType:      Usage,
+}
+
+// Object is a securable object together with its owner and ACL list.
+type Object struct {
+	Kind ObjectKind `json:"kind"`
+
+	// Schema is empty for objects that are not schema-qualified, e.g.
+	// databases, languages and schemas themselves.
+	Schema string `json:"schema,omitempty"`
+
+	// Name is the name of the object.  For columns it is the name of the
+	// relation the column belongs to.  Large objects have no name.
+	Name string `json:"name,omitempty"`
+
+	// Column is the column name for KindColumn objects.
+	Column string `json:"column,omitempty"`
+
+	// Signature is the identity argument list of a function or procedure,
+	// e.g. "integer, text".
+	Signature string `json:"signature,omitempty"`
+
+	OID   uint32 `json:"oid,omitempty"`
+	Owner string `json:"owner,omitempty"`
+	ACL   []ACL  `json:"acl"`
+
+	// InitialACL is the ACL recorded in pg_init_privs for objects created by
+	// an extension, or nil for all other objects.
+	InitialACL []ACL `json:"initial_acl,omitempty"`
+}
+
+// Baseline returns the ACL the object started out with: its InitialACL if it
+// has one, otherwise the ACLDefault of its owner.  Privileges that match the
+// baseline were not granted by the database's users.
+func (o Object) Baseline() []ACL {
+	if o.InitialACL != nil {
+		return o.InitialACL
+	}
+
+	return o.Kind.ACLDefault(o.Owner)
+}
+
+// Key returns a string that uniquely identifies the object within a database
+// and does not change across OID reassignment, e.g. a dump and restore.
+// Large objects are the exception and are keyed by OID.
+func (o Object) Key() string {
+	b := bytes.NewBufferString(string(o.Kind))
+	fmt.Fprint(b, ":", o.identity())
+
+	if o.Kind == KindColumn {
+		fmt.Fprint(b, ".", pq.QuoteIdentifier(o.Column))
+	}
+
+	return b.String()
+}
+
+// Validate checks every entry in the object's ACL list against the
+// privileges valid for its kind.
+func (o Object) Validate() error {
+	for _, acl := range o.ACL {
+		if err := o.Kind.Validate(acl); err != nil {
+			return fmt.Errorf("%s: %w", o.Key(), err)
+		}
+	}
+
+	return nil
+}
+
+// Grants returns the GRANT statements that constitute the privileges
+// specified in the object's ACL list, one per privilege.
+func (o Object) Grants() Statements {
+	return statements(Grant, o, "", o.ACL)
+}
+
+// Revokes returns the REVOKE statements that remove the privileges specified
+// in the object's ACL list.  Privileges held with the grant option only have
+// the grant option revoked.
+func (o Object) Revokes() Statements {
+	return statements(Revoke, o, "", o.ACL)
+}
+
+// identity returns the quoted, qualified name of the object.
+func (o Object) identity() string {
+	if o.Kind == KindLargeObject {
+		return strconv.FormatUint(uint64(o.OID), 10)
+	}
+
+	name := pq.QuoteIdentifier(o.Name)
+	if o.Schema != "" {
+		name = pq.QuoteIdentifier(o.Schema) + "." + name
+	}
+
+	if o.Kind.routine() {
+		name += "(" + o.Signature + ")"
+	}
+
+	return name
+}
+
+// target returns the ON clause of a GRANT or REVOKE for the object.
+func (o Object) target() string {
+	return objectKinds[o.Kind].keyword + " " + o.identity()
+}
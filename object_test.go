@@ -0,0 +1,243 @@
+package acl_test
+
+import (
+	"reflect"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+func TestObject(t *testing.T) {
+	tests := []struct {
+		name    string
+		obj     acl.Object
+		key     string
+		grants  []string
+		revokes []string
+		fail    bool
+	}{
+		{
+			name: "table",
+			obj: acl.Object{
+				Kind:   acl.KindTable,
+				Schema: "public",
+				Name:   "accounts",
+				ACL: []acl.ACL{
+					{Role: "ro", Privileges: acl.Select},
+					{Role: "app", Privileges: acl.Insert | acl.Update, GrantOptions: acl.Update},
+				},
+			},
+			key: `table:"public"."accounts"`,
+			grants: []string{
+				`GRANT SELECT ON TABLE "public"."accounts" TO "ro"`,
+				`GRANT INSERT ON TABLE "public"."accounts" TO "app"`,
+				`GRANT UPDATE ON TABLE "public"."accounts" TO "app" WITH GRANT OPTION`,
+			},
+			revokes: []string{
+				`REVOKE SELECT ON TABLE "public"."accounts" FROM "ro"`,
+				`REVOKE INSERT ON TABLE "public"."accounts" FROM "app"`,
+				`REVOKE GRANT OPTION FOR UPDATE ON TABLE "public"."accounts" FROM "app"`,
+			},
+		},
+		{
+			name: "column",
+			obj: acl.Object{
+				Kind:   acl.KindColumn,
+				Schema: "public",
+				Name:   "accounts",
+				Column: "email",
+				ACL:    []acl.ACL{{Role: "ro", Privileges: acl.Select}},
+			},
+			key:     `column:"public"."accounts"."email"`,
+			grants:  []string{`GRANT SELECT ("email") ON TABLE "public"."accounts" TO "ro"`},
+			revokes: []string{`REVOKE SELECT ("email") ON TABLE "public"."accounts" FROM "ro"`},
+		},
+		{
+			name: "function",
+			obj: acl.Object{
+				Kind:      acl.KindFunction,
+				Schema:    "app",
+				Name:      "report_daily",
+				Signature: "integer, text",
+				ACL:       []acl.ACL{{Privileges: acl.Execute}},
+			},
+			key:     `function:"app"."report_daily"(integer, text)`,
+			grants:  []string{`GRANT EXECUTE ON FUNCTION "app"."report_daily"(integer, text) TO PUBLIC`},
+			revokes: []string{`REVOKE EXECUTE ON FUNCTION "app"."report_daily"(integer, text) FROM PUBLIC`},
+		},
+		{
+			name: "procedure",
+			obj: acl.Object{
+				Kind:      acl.KindProcedure,
+				Schema:    "app",
+				Name:      "archive",
+				Signature: "date",
+				ACL:       []acl.ACL{{Role: "ops", Privileges: acl.Execute}},
+			},
+			key:     `procedure:"app"."archive"(date)`,
+			grants:  []string{`GRANT EXECUTE ON PROCEDURE "app"."archive"(date) TO "ops"`},
+			revokes: []string{`REVOKE EXECUTE ON PROCEDURE "app"."archive"(date) FROM "ops"`},
+		},
+		{
+			name: "sequence",
+			obj: acl.Object{
+				Kind:   acl.KindSequence,
+				Schema: "public",
+				Name:   "accounts_id_seq",
+				ACL:    []acl.ACL{{Role: "app", Privileges: acl.Usage}},
+			},
+			key:     `sequence:"public"."accounts_id_seq"`,
+			grants:  []string{`GRANT USAGE ON SEQUENCE "public"."accounts_id_seq" TO "app"`},
+			revokes: []string{`REVOKE USAGE ON SEQUENCE "public"."accounts_id_seq" FROM "app"`},
+		},
+		{
+			name: "large object",
+			obj: acl.Object{
+				Kind: acl.KindLargeObject,
+				OID:  16440,
+				ACL:  []acl.ACL{{Role: "ro", Privileges: acl.Select}},
+			},
+			key:     `large_object:16440`,
+			grants:  []string{`GRANT SELECT ON LARGE OBJECT 16440 TO "ro"`},
+			revokes: []string{`REVOKE SELECT ON LARGE OBJECT 16440 FROM "ro"`},
+		},
+		{
+			name: "foreign server",
+			obj: acl.Object{
+				Kind: acl.KindForeignServer,
+				Name: "remote",
+				ACL:  []acl.ACL{{Role: "app", Privileges: acl.Usage, GrantOptions: acl.Usage}},
+			},
+			key:     `foreign_server:"remote"`,
+			grants:  []string{`GRANT USAGE ON FOREIGN SERVER "remote" TO "app" WITH GRANT OPTION`},
+			revokes: []string{`REVOKE GRANT OPTION FOR USAGE ON FOREIGN SERVER "remote" FROM "app"`},
+		},
+		{
+			name: "database",
+			obj: acl.Object{
+				Kind: acl.KindDatabase,
+				Name: "appdb",
+				ACL:  []acl.ACL{{Privileges: acl.Connect | acl.Temporary}},
+			},
+			key: `database:"appdb"`,
+			grants: []string{
+				`GRANT CONNECT ON DATABASE "appdb" TO PUBLIC`,
+				`GRANT TEMPORARY ON DATABASE "appdb" TO PUBLIC`,
+			},
+			revokes: []string{
+				`REVOKE CONNECT ON DATABASE "appdb" FROM PUBLIC`,
+				`REVOKE TEMPORARY ON DATABASE "appdb" FROM PUBLIC`,
+			},
+		},
+		{
+			name: "invalid privilege for kind",
+			obj: acl.Object{
+				Kind:   acl.KindSequence,
+				Schema: "public",
+				Name:   "accounts_id_seq",
+				ACL:    []acl.ACL{{Role: "app", Privileges: acl.Insert}},
+			},
+			key:  `sequence:"public"."accounts_id_seq"`,
+			fail: true,
+		},
+		{
+			name: "unknown kind",
+			obj: acl.Object{
+				Kind: "widget",
+				Name: "w",
+				ACL:  []acl.ACL{{Role: "app", Privileges: acl.Usage}},
+			},
+			key:  `widget:"w"`,
+			fail: true,
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			if key := test.obj.Key(); key != test.key {
+				t.Fatalf("want %+q got %+q", test.key, key)
+			}
+
+			err := test.obj.Validate()
+			if err != nil && !test.fail {
+				t.Fatalf("unable to validate object: %v", err)
+			}
+
+			if err == nil && test.fail {
+				t.Fatalf("expected failure")
+			}
+
+			if test.fail {
+				return
+			}
+
+			grants := test.obj.Grants().SQL()
+			if !reflect.DeepEqual(test.grants, grants) {
+				t.Fatalf("bad: expected %#v to equal %#v", test.grants, grants)
+			}
+
+			revokes := test.obj.Revokes().SQL()
+			if !reflect.DeepEqual(test.revokes, revokes) {
+				t.Fatalf("bad: expected %#v to equal %#v", test.revokes, revokes)
+			}
+		})
+	}
+}
+
+func TestParseObjectKind(t *testing.T) {
+	kind, err := acl.ParseObjectKind("foreign_data_wrapper")
+	if err != nil {
+		t.Fatalf("unable to parse object kind: %v", err)
+	}
+
+	if kind != acl.KindForeignDataWrapper {
+		t.Fatalf("bad: expected %v to equal %v", acl.KindForeignDataWrapper, kind)
+	}
+
+	if got := kind.ValidPrivileges(); got != acl.Usage {
+		t.Fatalf("bad: expected %v to equal %v", acl.Usage, got)
+	}
+
+	if _, err := acl.ParseObjectKind("view"); err == nil {
+		t.Fatalf("expected failure")
+	}
+}
+
+func TestACLDefault(t *testing.T) {
+	tests := []struct {
+		name string
+		kind acl.ObjectKind
+		want []string
+	}{
+		{name: "table", kind: acl.KindTable, want: []string{"app=arwdDxt/app"}},
+		{name: "sequence", kind: acl.KindSequence, want: []string{"app=rwU/app"}},
+		{name: "database", kind: acl.KindDatabase, want: []string{"=Tc/app", "app=CTc/app"}},
+		{name: "function", kind: acl.KindFunction, want: []string{"=X/app", "app=X/app"}},
+		{name: "procedure", kind: acl.KindProcedure, want: []string{"=X/app", "app=X/app"}},
+		{name: "domain", kind: acl.KindDomain, want: []string{"=U/app", "app=U/app"}},
+		{name: "schema", kind: acl.KindSchema, want: []string{"app=UC/app"}},
+		{name: "large object", kind: acl.KindLargeObject, want: []string{"app=rw/app"}},
+		{name: "column", kind: acl.KindColumn},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, a := range test.kind.ACLDefault("app") {
+				got = append(got, a.String())
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("bad: expected %#v to equal %#v", got, test.want)
+			}
+		})
+	}
+}
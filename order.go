@@ -0,0 +1,84 @@
+package acl
+
+import "sort"
+
+// Tiers of the dependency order used by Order.  Each tier depends only on
+// the tiers before it: objects cannot be used without USAGE on their schema,
+// a foreign server without its wrapper, and privileges granted through a role
+// without membership in the role.
+const (
+	tierMembership = iota
+	tierContainer
+	tierServer
+	tierSchema
+	tierObject
+	tierColumn
+	tierDefault
+)
+
+// kindTiers maps each object kind to its tier.
+var kindTiers = map[ObjectKind]int{
+	KindDatabase:           tierContainer,
+	KindTablespace:         tierContainer,
+	KindLanguage:           tierContainer,
+	KindForeignDataWrapper: tierContainer,
+	KindForeignServer:      tierServer,
+	KindSchema:             tierSchema,
+	KindDomain:             tierObject,
+	KindFunction:           tierObject,
+	KindLargeObject:        tierObject,
+	KindProcedure:          tierObject,
+	KindRoutine:            tierObject,
+	KindSequence:           tierObject,
+	KindTable:              tierObject,
+	KindType:               tierObject,
+	KindColumn:             tierColumn,
+}
+
+// Order returns stmts sorted so that each statement runs after the ones it
+// depends on.  Revokes come first, in reverse dependency order: default
+// privileges, then columns and objects, then schemas, then databases and
+// other containers, and role memberships last.  Within a tier, revokes of
+// grant options precede revokes of privileges, so dependent grant options are
+// gone before the privileges they depend on.  Grants follow in dependency
+// order: role memberships, containers, schema USAGE, objects, columns and
+// default privileges.  The sort is stable, so statements in the same position
+// keep their relative order.
+//
+// Statements that create roles are not generated by this package and must
+// run before everything Order returns.
+func Order(stmts Statements) Statements {
+	sorted := append(Statements(nil), stmts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].rank() < sorted[j].rank()
+	})
+
+	return sorted
+}
+
+// rank returns the position of the statement in the order used by Order.
+func (s Statement) rank() int {
+	tier := s.tier()
+	if s.Action == Grant {
+		return 100 + tier
+	}
+
+	rank := 2 * (tierDefault - tier)
+	if !s.WithGrantOption {
+		rank++
+	}
+
+	return rank
+}
+
+// tier returns the dependency tier of the statement.
+func (s Statement) tier() int {
+	switch {
+	case len(s.Roles) > 0:
+		return tierMembership
+	case s.isDefault():
+		return tierDefault
+	}
+
+	return kindTiers[s.kind()]
+}
@@ -0,0 +1,63 @@
+package acl_test
+
+import (
+	"reflect"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+func TestOrder(t *testing.T) {
+	schema := acl.Object{Kind: acl.KindSchema, Name: "app"}
+	table := acl.Object{Kind: acl.KindTable, Schema: "app", Name: "accounts"}
+	column := acl.Object{Kind: acl.KindColumn, Schema: "app", Name: "users", Column: "email"}
+	database := acl.Object{Kind: acl.KindDatabase, Name: "appdb"}
+	server := acl.Object{Kind: acl.KindForeignServer, Name: "remote"}
+	fdw := acl.Object{Kind: acl.KindForeignDataWrapper, Name: "postgres_fdw"}
+
+	stmt := func(action acl.Action, priv acl.Privileges, obj acl.Object, grantOption bool) acl.Statement {
+		return acl.Statement{
+			Action:          action,
+			Privileges:      priv,
+			WithGrantOption: grantOption,
+			Objects:         []acl.Object{obj},
+			Grantees:        []string{"ro"},
+		}
+	}
+
+	stmts := acl.Statements{
+		stmt(acl.Grant, acl.Select, table, false),
+		stmt(acl.Revoke, acl.Usage, schema, false),
+		{Action: acl.Grant, Privileges: acl.Select, Objects: []acl.Object{{Kind: acl.KindTable, Schema: "app"}}, Grantees: []string{"ro"}, DefaultFor: "app"},
+		stmt(acl.Grant, acl.Select, column, false),
+		stmt(acl.Revoke, acl.Select, table, false),
+		stmt(acl.Grant, acl.Usage, schema, false),
+		stmt(acl.Revoke, acl.Update, table, true),
+		{Action: acl.Grant, Roles: []string{"readers"}, Grantees: []string{"ro"}},
+		stmt(acl.Grant, acl.Usage, server, false),
+		stmt(acl.Grant, acl.Connect, database, false),
+		stmt(acl.Grant, acl.Usage, fdw, false),
+		{Action: acl.Revoke, Roles: []string{"writers"}, Grantees: []string{"ro"}},
+		stmt(acl.Grant, acl.Insert, table, false),
+	}
+
+	want := []string{
+		`REVOKE GRANT OPTION FOR UPDATE ON TABLE "app"."accounts" FROM "ro"`,
+		`REVOKE SELECT ON TABLE "app"."accounts" FROM "ro"`,
+		`REVOKE USAGE ON SCHEMA "app" FROM "ro"`,
+		`REVOKE "writers" FROM "ro"`,
+		`GRANT "readers" TO "ro"`,
+		`GRANT CONNECT ON DATABASE "appdb" TO "ro"`,
+		`GRANT USAGE ON FOREIGN DATA WRAPPER "postgres_fdw" TO "ro"`,
+		`GRANT USAGE ON FOREIGN SERVER "remote" TO "ro"`,
+		`GRANT USAGE ON SCHEMA "app" TO "ro"`,
+		`GRANT SELECT ON TABLE "app"."accounts" TO "ro"`,
+		`GRANT INSERT ON TABLE "app"."accounts" TO "ro"`,
+		`GRANT SELECT ("email") ON TABLE "app"."users" TO "ro"`,
+		`ALTER DEFAULT PRIVILEGES FOR ROLE "app" IN SCHEMA "app" GRANT SELECT ON TABLES TO "ro"`,
+	}
+
+	if got := acl.Order(stmts).SQL(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("bad: expected %#v to equal %#v", want, got)
+	}
+}
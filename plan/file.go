@@ -0,0 +1,119 @@
+package plan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	acl "github.com/sean-/postgresql-acl"
+	"github.com/sean-/postgresql-acl/snapshot"
+)
+
+// FileVersion is the plan file format version written by File.Write.
+const FileVersion = 1
+
+// File is a saved plan: the statements to run and a hash of the state they
+// were computed from, so they are not applied to a database that has changed
+// since.  The desired state and managed roles are saved too, so the database
+// can be checked after the plan is applied.
+type File struct {
+	Version int `json:"version"`
+
+	// Schemas are the schemas the state was loaded from.  Empty means all
+	// but the system schemas.
+	Schemas []string `json:"schemas,omitempty"`
+
+	StateHash  string   `json:"state_hash"`
+	Statements []string `json:"statements"`
+
+	Desired State    `json:"desired"`
+	Roles   []string `json:"roles"`
+}
+
+// ErrStale is returned by File.Check when the state has changed since the
+// plan was made.
+var ErrStale = errors.New("ACLs have changed since the plan was made")
+
+// Hash returns a digest of the ACLs, owners and default privileges in s.  The
+// order of objects and ACL entries and the OIDs of named objects do not
+// affect the result.
+func Hash(s State) (string, error) {
+	snap := &snapshot.Snapshot{
+		Objects:     append([]acl.Object(nil), s.Objects...),
+		DefaultACLs: append([]acl.DefaultACL(nil), s.DefaultACLs...),
+	}
+
+	h := sha256.New()
+	if err := snap.Write(h); err != nil {
+		return "", err
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// NewFile returns a plan file for stmts computed from desired and actual for
+// roles.
+func NewFile(stmts []string, desired, actual State, roles, schemas []string) (*File, error) {
+	hash, err := Hash(actual)
+	if err != nil {
+		return nil, err
+	}
+
+	if stmts == nil {
+		stmts = []string{}
+	}
+
+	return &File{
+		Version:    FileVersion,
+		Schemas:    schemas,
+		StateHash:  hash,
+		Statements: stmts,
+		Desired:    desired,
+		Roles:      roles,
+	}, nil
+}
+
+// Check returns ErrStale unless actual hashes to the state the plan was
+// computed from.
+func (f *File) Check(actual State) error {
+	hash, err := Hash(actual)
+	if err != nil {
+		return err
+	}
+
+	if hash != f.StateHash {
+		return ErrStale
+	}
+
+	return nil
+}
+
+// Write writes the plan file to w as indented JSON.
+func (f *File) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(f)
+}
+
+// ReadFile parses a plan file written by File.Write.
+func ReadFile(r io.Reader) (*File, error) {
+	f := &File{}
+	if err := json.NewDecoder(r).Decode(f); err != nil {
+		return nil, fmt.Errorf("unable to decode plan: %w", err)
+	}
+
+	if f.Version < 1 || f.Version > FileVersion {
+		return nil, fmt.Errorf("unsupported plan version %d, only versions 1 through %d are supported", f.Version, FileVersion)
+	}
+
+	if f.StateHash == "" {
+		return nil, fmt.Errorf("plan has no state hash")
+	}
+
+	return f, nil
+}
@@ -0,0 +1,118 @@
+package plan_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+	"github.com/sean-/postgresql-acl/plan"
+)
+
+func TestHash(t *testing.T) {
+	base := plan.State{Objects: []acl.Object{
+		table("accounts", "owner=arwdDxt/owner", "ro=r/owner"),
+		table("ledger", "owner=arwdDxt/owner"),
+	}}
+
+	reordered := plan.State{Objects: []acl.Object{
+		table("ledger", "owner=arwdDxt/owner"),
+		table("accounts", "ro=r/owner", "owner=arwdDxt/owner"),
+	}}
+	reordered.Objects[0].OID = 16385
+
+	changed := plan.State{Objects: []acl.Object{
+		table("accounts", "owner=arwdDxt/owner", "ro=rw/owner"),
+		table("ledger", "owner=arwdDxt/owner"),
+	}}
+
+	withDefaults := plan.State{
+		Objects: base.Objects,
+		DefaultACLs: []acl.DefaultACL{{
+			Role: "owner", Kind: acl.KindTable, ACL: []acl.ACL{mustParse("ro=r/owner")},
+		}},
+	}
+
+	hash := func(s plan.State) string {
+		h, err := plan.Hash(s)
+		if err != nil {
+			t.Fatalf("unable to hash state: %v", err)
+		}
+		return h
+	}
+
+	if !strings.HasPrefix(hash(base), "sha256:") {
+		t.Fatalf("bad: unexpected hash format %q", hash(base))
+	}
+
+	if hash(base) != hash(reordered) {
+		t.Fatalf("bad: expected order and OIDs not to change the hash")
+	}
+
+	if hash(base) == hash(changed) {
+		t.Fatalf("bad: expected a changed ACL to change the hash")
+	}
+
+	if hash(base) == hash(withDefaults) {
+		t.Fatalf("bad: expected default privileges to change the hash")
+	}
+}
+
+func TestFile(t *testing.T) {
+	actual := plan.State{Objects: []acl.Object{table("accounts", "owner=arwdDxt/owner")}}
+	stmts := []string{`GRANT SELECT ON TABLE "app"."accounts" TO "ro"`}
+
+	desired := plan.State{Objects: []acl.Object{table("accounts", "owner=arwdDxt/owner", "ro=r/owner")}}
+	f, err := plan.NewFile(stmts, desired, actual, []string{"ro"}, []string{"app"})
+	if err != nil {
+		t.Fatalf("unable to create plan file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatalf("unable to write plan file: %v", err)
+	}
+
+	read, err := plan.ReadFile(&buf)
+	if err != nil {
+		t.Fatalf("unable to read plan file: %v", err)
+	}
+
+	if !reflect.DeepEqual(f, read) {
+		t.Fatalf("bad: expected %+v to equal %+v", f, read)
+	}
+
+	if err := read.Check(actual); err != nil {
+		t.Fatalf("bad: unexpected error checking unchanged state: %v", err)
+	}
+
+	changed := plan.State{Objects: []acl.Object{table("accounts", "owner=arwdDxt/owner", "ro=r/owner")}}
+	if err := read.Check(changed); !errors.Is(err, plan.ErrStale) {
+		t.Fatalf("bad: expected %v, got %v", plan.ErrStale, err)
+	}
+}
+
+func TestReadFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{name: "not JSON", in: "version: 1\n"},
+		{name: "unsupported version", in: `{"version": 2, "state_hash": "sha256:00", "statements": []}`},
+		{name: "missing hash", in: `{"version": 1, "statements": []}`},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := plan.ReadFile(strings.NewReader(test.in)); err == nil {
+				t.Fatalf("expected failure")
+			}
+		})
+	}
+}
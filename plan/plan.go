@@ -0,0 +1,162 @@
+// Package plan computes the GRANT, REVOKE and ALTER DEFAULT PRIVILEGES
+// statements that converge the privileges in a database on a desired state,
+// such as the one produced by expanding a policy.
+//
+// Only the privileges of the managed roles are changed.  Privileges held by
+// other roles, and those an object's owner holds on its own objects, are
+// left alone.  Privileges granted by a role other than the owner are revoked
+// with GRANTED BY that role, as a REVOKE only removes the privileges its
+// grantor granted.  Neither are the privileges an extension granted when it
+// created an object, as recorded in pg_init_privs.
+package plan
+
+import (
+	"sort"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// State is the privileges on a set of objects and the default privileges.
+type State struct {
+	Objects     []acl.Object     `json:"objects"`
+	DefaultACLs []acl.DefaultACL `json:"default_acls"`
+}
+
+// Compute returns the statements that turn the privileges of roles in actual
+// into those in desired.  The empty role stands for PUBLIC.  Objects that
+// appear in desired but not in actual, such as columns without column
+// privileges, are treated as having no privileges.  Privileges a role holds
+// in the InitialACL of an actual object are never revoked.  The statements are
+// ordered by acl.Order: all revokes precede all grants, which also keeps
+// statements with the same action together for acl.Compact.
+func Compute(desired, actual State, roles []string) acl.Statements {
+	var revokeStmts, grantStmts acl.Statements
+
+	want, have := indexObjects(desired.Objects), indexObjects(actual.Objects)
+	for _, key := range sortedKeys(want, have) {
+		obj, ok := have[key]
+		if !ok {
+			obj = want[key]
+		}
+
+		revokes, grants := changes(want[key].ACL, have[key].ACL, have[key].InitialACL, roles, obj.Owner)
+		for _, r := range revokes {
+			obj.ACL = []acl.ACL{r}
+			for _, s := range obj.Revokes() {
+				s.GrantedBy = r.GrantedBy
+				revokeStmts = append(revokeStmts, s)
+			}
+		}
+		obj.ACL = grants
+		grantStmts = append(grantStmts, obj.Grants()...)
+	}
+
+	wantDefs, haveDefs := indexDefaults(desired.DefaultACLs), indexDefaults(actual.DefaultACLs)
+	for _, key := range sortedKeys(wantDefs, haveDefs) {
+		def, ok := haveDefs[key]
+		if !ok {
+			def = wantDefs[key]
+		}
+
+		revokes, grants := changes(wantDefs[key].ACL, haveDefs[key].ACL, nil, roles, def.Role)
+		def.ACL = revokes
+		revokeStmts = append(revokeStmts, def.Revokes()...)
+		def.ACL = grants
+		grantStmts = append(grantStmts, def.Grants()...)
+	}
+
+	return acl.Order(append(revokeStmts, grantStmts...))
+}
+
+// changes compares the privileges each role holds in want and have,
+// regardless of grantor, and returns the revokes followed by the grants that
+// remove the difference.  owner is skipped, and the privileges and grant
+// options each role holds in initial are kept.  Revokes are made per grantor
+// of have, and carry the grantor unless it is owner.
+func changes(want, have, initial []acl.ACL, roles []string, owner string) (revokes, grants []acl.ACL) {
+	for _, role := range roles {
+		if role == owner {
+			continue
+		}
+
+		wantPrivs, wantOpts := held(want, role)
+		havePrivs, haveOpts := held(have, role)
+		initPrivs, initOpts := held(initial, role)
+
+		for _, a := range have {
+			if a.Role != role {
+				continue
+			}
+
+			grantor := a.GrantedBy
+			if grantor == owner {
+				grantor = ""
+			}
+
+			// Revoking a privilege also revokes its grant option, so only
+			// the grant options of retained privileges are revoked on their
+			// own.
+			if extra := a.Privileges &^ wantPrivs &^ initPrivs; extra != acl.NoPrivs {
+				revokes = append(revokes, acl.ACL{Role: role, GrantedBy: grantor, Privileges: extra})
+			}
+
+			if extra := a.GrantOptions &^ wantOpts &^ initOpts & wantPrivs; extra != acl.NoPrivs {
+				revokes = append(revokes, acl.ACL{Role: role, GrantedBy: grantor, Privileges: extra, GrantOptions: extra})
+			}
+		}
+
+		opts := wantOpts &^ haveOpts
+		if missing := wantPrivs&^havePrivs | opts; missing != acl.NoPrivs {
+			grants = append(grants, acl.ACL{Role: role, Privileges: missing, GrantOptions: opts})
+		}
+	}
+
+	return revokes, grants
+}
+
+// held returns the privileges and grant options role holds in acls from any
+// grantor.
+func held(acls []acl.ACL, role string) (privs, opts acl.Privileges) {
+	for _, a := range acls {
+		if a.Role == role {
+			privs |= a.Privileges
+			opts |= a.GrantOptions
+		}
+	}
+
+	return privs, opts
+}
+
+func indexObjects(objs []acl.Object) map[string]acl.Object {
+	m := make(map[string]acl.Object, len(objs))
+	for _, o := range objs {
+		m[o.Key()] = o
+	}
+
+	return m
+}
+
+func indexDefaults(defs []acl.DefaultACL) map[string]acl.DefaultACL {
+	m := make(map[string]acl.DefaultACL, len(defs))
+	for _, d := range defs {
+		m[d.Key()] = d
+	}
+
+	return m
+}
+
+// sortedKeys returns the union of the keys of a and b in order.
+func sortedKeys[T any](a, b map[string]T) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,165 @@
+package plan_test
+
+import (
+	"reflect"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+	"github.com/sean-/postgresql-acl/plan"
+)
+
+func table(name string, acls ...string) acl.Object {
+	obj := acl.Object{Kind: acl.KindTable, Schema: "app", Name: name, Owner: "owner"}
+	for _, s := range acls {
+		obj.ACL = append(obj.ACL, mustParse(s))
+	}
+
+	return obj
+}
+
+func mustParse(s string) acl.ACL {
+	a, err := acl.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name    string
+		desired plan.State
+		actual  plan.State
+		roles   []string
+		want    []string
+	}{
+		{
+			name:    "converged",
+			desired: plan.State{Objects: []acl.Object{table("accounts", "ro=r/owner")}},
+			actual:  plan.State{Objects: []acl.Object{table("accounts", "owner=arwdDxt/owner", "ro=r/owner")}},
+			roles:   []string{"ro"},
+		},
+		{
+			name:    "missing privilege",
+			desired: plan.State{Objects: []acl.Object{table("accounts", "ro=r/owner", "rw=arwd/owner")}},
+			actual:  plan.State{Objects: []acl.Object{table("accounts", "rw=r/owner")}},
+			roles:   []string{"ro", "rw"},
+			want: []string{
+				`GRANT SELECT ON TABLE "app"."accounts" TO "ro"`,
+				`GRANT DELETE ON TABLE "app"."accounts" TO "rw"`,
+				`GRANT INSERT ON TABLE "app"."accounts" TO "rw"`,
+				`GRANT UPDATE ON TABLE "app"."accounts" TO "rw"`,
+			},
+		},
+		{
+			name:    "extra privilege",
+			desired: plan.State{Objects: []acl.Object{table("accounts", "ro=r/owner")}},
+			actual:  plan.State{Objects: []acl.Object{table("accounts", "ro=rw/owner"), table("ledger", "ro=r/owner")}},
+			roles:   []string{"ro"},
+			want: []string{
+				`REVOKE UPDATE ON TABLE "app"."accounts" FROM "ro"`,
+				`REVOKE SELECT ON TABLE "app"."ledger" FROM "ro"`,
+			},
+		},
+		{
+			name:    "grant options",
+			desired: plan.State{Objects: []acl.Object{table("accounts", "ro=r/owner", "rw=a*r/owner")}},
+			actual:  plan.State{Objects: []acl.Object{table("accounts", "ro=r*/owner", "rw=ar/owner")}},
+			roles:   []string{"ro", "rw"},
+			want: []string{
+				`REVOKE GRANT OPTION FOR SELECT ON TABLE "app"."accounts" FROM "ro"`,
+				`GRANT INSERT ON TABLE "app"."accounts" TO "rw" WITH GRANT OPTION`,
+			},
+		},
+		{
+			name:    "other grantors",
+			desired: plan.State{Objects: []acl.Object{table("accounts", "ro=r/owner")}},
+			actual:  plan.State{Objects: []acl.Object{table("accounts", "ro=r/owner", "ro=r*w/lead", "ro=r/ops")}},
+			roles:   []string{"ro"},
+			want: []string{
+				`REVOKE GRANT OPTION FOR SELECT ON TABLE "app"."accounts" FROM "ro" GRANTED BY "lead"`,
+				`REVOKE UPDATE ON TABLE "app"."accounts" FROM "ro" GRANTED BY "lead"`,
+			},
+		},
+		{
+			name:    "unmanaged roles and owner",
+			desired: plan.State{Objects: []acl.Object{table("accounts")}},
+			actual:  plan.State{Objects: []acl.Object{table("accounts", "owner=arwdDxt/owner", "other=r/owner", "ro=r/owner")}},
+			roles:   []string{"owner", "ro"},
+			want:    []string{`REVOKE SELECT ON TABLE "app"."accounts" FROM "ro"`},
+		},
+		{
+			name:    "extension object",
+			desired: plan.State{Objects: []acl.Object{table("accounts")}},
+			actual: plan.State{Objects: []acl.Object{func() acl.Object {
+				obj := table("accounts", "ro=rw*/owner")
+				obj.InitialACL = []acl.ACL{mustParse("ro=w*/owner")}
+				return obj
+			}()}},
+			roles: []string{"ro"},
+			want:  []string{`REVOKE SELECT ON TABLE "app"."accounts" FROM "ro"`},
+		},
+		{
+			name: "PUBLIC and new column",
+			desired: plan.State{Objects: []acl.Object{{
+				Kind: acl.KindColumn, Schema: "app", Name: "users", Column: "email", Owner: "owner",
+				ACL: []acl.ACL{mustParse("=r/owner")},
+			}}},
+			roles: []string{""},
+			want:  []string{`GRANT SELECT ("email") ON TABLE "app"."users" TO PUBLIC`},
+		},
+		{
+			name: "dependency order",
+			desired: plan.State{Objects: []acl.Object{
+				{Kind: acl.KindSchema, Name: "app", Owner: "owner", ACL: []acl.ACL{mustParse("ro=U/owner")}},
+				table("accounts", "ro=r/owner"),
+			}},
+			actual: plan.State{Objects: []acl.Object{
+				{Kind: acl.KindSchema, Name: "app", Owner: "owner"},
+				{Kind: acl.KindSchema, Name: "old", Owner: "owner", ACL: []acl.ACL{mustParse("ro=U/owner")}},
+				table("accounts", "ro=r*w*/owner"),
+			}},
+			roles: []string{"ro"},
+			want: []string{
+				`REVOKE GRANT OPTION FOR SELECT ON TABLE "app"."accounts" FROM "ro"`,
+				`REVOKE UPDATE ON TABLE "app"."accounts" FROM "ro"`,
+				`REVOKE USAGE ON SCHEMA "old" FROM "ro"`,
+				`GRANT USAGE ON SCHEMA "app" TO "ro"`,
+			},
+		},
+		{
+			name: "default privileges",
+			desired: plan.State{DefaultACLs: []acl.DefaultACL{{
+				Role: "owner", Schema: "app", Kind: acl.KindTable,
+				ACL: []acl.ACL{mustParse("ro=r/owner")},
+			}}},
+			actual: plan.State{DefaultACLs: []acl.DefaultACL{
+				{Role: "owner", Schema: "app", Kind: acl.KindTable, ACL: []acl.ACL{mustParse("rw=r/owner")}},
+				{Role: "owner", Kind: acl.KindFunction, ACL: []acl.ACL{mustParse("owner=X/owner"), mustParse("ro=X/owner")}},
+			}},
+			roles: []string{"owner", "ro", "rw"},
+			want: []string{
+				`ALTER DEFAULT PRIVILEGES FOR ROLE "owner" REVOKE EXECUTE ON FUNCTIONS FROM "ro"`,
+				`ALTER DEFAULT PRIVILEGES FOR ROLE "owner" IN SCHEMA "app" REVOKE SELECT ON TABLES FROM "rw"`,
+				`ALTER DEFAULT PRIVILEGES FOR ROLE "owner" IN SCHEMA "app" GRANT SELECT ON TABLES TO "ro"`,
+			},
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			if stmts := plan.Compute(test.desired, test.actual, test.roles); stmts != nil {
+				got = stmts.SQL()
+			}
+			if !reflect.DeepEqual(test.want, got) {
+				t.Fatalf("bad: expected %#v to equal %#v", test.want, got)
+			}
+		})
+	}
+}
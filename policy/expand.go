@@ -0,0 +1,179 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// Expand resolves the selectors of every rule against an inventory of
+// existing objects, such as the one returned by catalog.Catalog.Objects, and
+// returns the desired ACL list of each object a rule applies to.  Desired
+// entries are granted by the object's owner.  Objects named without wildcards
+// that do not exist in the inventory are reported as errors.
+func (p *Policy) Expand(inventory []acl.Object) ([]acl.Object, error) {
+	desired := make(map[string]*acl.Object)
+	var errs []error
+
+	for _, rule := range p.Rules {
+		for _, sel := range rule.Selectors {
+			matches := sel.Resolve(inventory)
+			if len(matches) == 0 && sel.Exact() {
+				errs = append(errs, &Error{
+					Pos: rule.Pos,
+					Err: fmt.Errorf("%s %+q does not exist", rule.Kind, sel),
+				})
+				continue
+			}
+
+			for _, obj := range matches {
+				for _, target := range rule.targets(obj) {
+					key := target.Key()
+					d, ok := desired[key]
+					if !ok {
+						target.ACL = nil
+						d = &target
+						desired[key] = d
+					}
+
+					d.ACL = grant(d.ACL, rule, obj.Owner)
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	objs := make([]acl.Object, 0, len(desired))
+	for _, d := range desired {
+		sort.Slice(d.ACL, func(i, j int) bool {
+			return d.ACL[i].Role < d.ACL[j].Role
+		})
+		objs = append(objs, *d)
+	}
+
+	sort.Slice(objs, func(i, j int) bool {
+		return objs[i].Key() < objs[j].Key()
+	})
+
+	return objs, nil
+}
+
+// DefaultACLs returns the default privileges requested by rules with
+// defaults_for, one entry per creating role, schema and kind.  Desired entries
+// are granted by the creating role.
+func (p *Policy) DefaultACLs() []acl.DefaultACL {
+	desired := make(map[string]*acl.DefaultACL)
+	for _, rule := range p.Rules {
+		schemas := rule.Schemas
+		if len(schemas) == 0 {
+			schemas = []string{""}
+		}
+
+		for _, owner := range rule.DefaultsFor {
+			for _, schema := range schemas {
+				def := acl.DefaultACL{Role: owner, Schema: schema, Kind: rule.Kind}
+				d, ok := desired[def.Key()]
+				if !ok {
+					d = &def
+					desired[def.Key()] = d
+				}
+
+				d.ACL = grant(d.ACL, rule, owner)
+			}
+		}
+	}
+
+	defs := make([]acl.DefaultACL, 0, len(desired))
+	for _, d := range desired {
+		sort.Slice(d.ACL, func(i, j int) bool {
+			return d.ACL[i].Role < d.ACL[j].Role
+		})
+		defs = append(defs, *d)
+	}
+
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Key() < defs[j].Key()
+	})
+
+	return defs
+}
+
+// Roles returns the sorted, distinct roles the policy grants privileges to.
+// PUBLIC is returned as the empty role, as in an ACL.
+func (p *Policy) Roles() []string {
+	seen := make(map[string]bool)
+	var roles []string
+	for _, rule := range p.Rules {
+		role := rule.role()
+		if !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+
+	return roles
+}
+
+// role returns the grantee of the rule, with PUBLIC as the empty role.
+func (r Rule) role() string {
+	if strings.EqualFold(r.Role, "public") {
+		return ""
+	}
+
+	return r.Role
+}
+
+// targets returns the objects a matching rule grants privileges on: obj
+// itself, or one object per column for column rules.
+func (r Rule) targets(obj acl.Object) []acl.Object {
+	if r.Kind != acl.KindColumn {
+		return []acl.Object{obj}
+	}
+
+	cols := make([]acl.Object, 0, len(r.Columns))
+	for _, c := range r.Columns {
+		cols = append(cols, acl.Object{
+			Kind:   acl.KindColumn,
+			Schema: obj.Schema,
+			Name:   obj.Name,
+			Column: c,
+			OID:    obj.OID,
+			Owner:  obj.Owner,
+		})
+	}
+
+	return cols
+}
+
+// grant adds the rule's privileges to the role's entry in acls, creating the
+// entry if necessary.
+func grant(acls []acl.ACL, r Rule, owner string) []acl.ACL {
+	role := r.role()
+
+	grantOptions := acl.NoPrivs
+	if r.WithGrantOption {
+		grantOptions = r.Privileges
+	}
+
+	for i := range acls {
+		if acls[i].Role == role {
+			acls[i].Privileges |= r.Privileges
+			acls[i].GrantOptions |= grantOptions
+			return acls
+		}
+	}
+
+	return append(acls, acl.ACL{
+		Role:         role,
+		GrantedBy:    owner,
+		Privileges:   r.Privileges,
+		GrantOptions: grantOptions,
+	})
+}
@@ -0,0 +1,193 @@
+package policy_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+	"github.com/sean-/postgresql-acl/policy"
+)
+
+func inventory() []acl.Object {
+	return []acl.Object{
+		{Kind: acl.KindSchema, Name: "app", Owner: "owner"},
+		{Kind: acl.KindSchema, Name: "billing", Owner: "owner"},
+		{Kind: acl.KindTable, Schema: "app", Name: "accounts", Owner: "owner"},
+		{Kind: acl.KindTable, Schema: "app", Name: "ledger", Owner: "owner"},
+		{Kind: acl.KindTable, Schema: "app", Name: "users", Owner: "owner"},
+		{Kind: acl.KindTable, Schema: "billing", Name: "invoices", Owner: "owner"},
+		{Kind: acl.KindFunction, Schema: "app", Name: "report_daily", Signature: "integer", Owner: "owner"},
+		{Kind: acl.KindFunction, Schema: "app", Name: "report_weekly", Signature: "", Owner: "owner"},
+		{Kind: acl.KindFunction, Schema: "app", Name: "reset", Signature: "", Owner: "owner"},
+	}
+}
+
+func TestExpand(t *testing.T) {
+	p, err := policy.Parse("app.yaml", []byte(appPolicy))
+	if err != nil {
+		t.Fatalf("unable to parse policy: %v", err)
+	}
+
+	objs, err := p.Expand(inventory())
+	if err != nil {
+		t.Fatalf("unable to expand policy: %v", err)
+	}
+
+	got := make(map[string][]string)
+	for _, o := range objs {
+		for _, a := range o.ACL {
+			got[o.Key()] = append(got[o.Key()], a.String())
+		}
+	}
+
+	want := map[string][]string{
+		`column:"app"."users"."email"`:           {"app_rw=rw/owner"},
+		`function:"app"."report_daily"(integer)`: {"app_ro=X/owner"},
+		`function:"app"."report_weekly"()`:       {"app_ro=X/owner"},
+		`schema:"app"`:                           {"app_ro=U/owner"},
+		`table:"app"."accounts"`:                 {"app_ro=r/owner", "app_rw=a*r*w*d*D*x*t*/owner"},
+		`table:"app"."ledger"`:                   {"app_ro=r/owner", "app_rw=a*r*w*d*D*x*t*/owner"},
+		`table:"app"."users"`:                    {"app_ro=r/owner"},
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("bad: expected %v to equal %v", want, got)
+	}
+}
+
+func TestExpandMissingObject(t *testing.T) {
+	const in = `version: 1
+roles:
+  app_ro:
+    - on: table
+      objects: [app.accounts, app.missing, "app.tmp_*"]
+      privileges: [SELECT]
+    - on: function
+      objects: ["app.report_daily(text)"]
+      privileges: [EXECUTE]
+`
+
+	p, err := policy.Parse("p.yaml", []byte(in))
+	if err != nil {
+		t.Fatalf("unable to parse policy: %v", err)
+	}
+
+	_, err = p.Expand(inventory())
+	if err == nil {
+		t.Fatalf("expected failure")
+	}
+
+	want := []string{
+		`p.yaml:4:7: table "app.missing" does not exist`,
+		`p.yaml:7:7: function "app.report_daily(text)" does not exist`,
+	}
+
+	if got := strings.Split(err.Error(), "\n"); !reflect.DeepEqual(want, got) {
+		t.Fatalf("bad: expected %q to equal %q", want, got)
+	}
+}
+
+func TestExpandPicksUpNewObjects(t *testing.T) {
+	const in = `version: 1
+roles:
+  cleaner:
+    - on: table
+      objects: ["app.tmp_%"]
+      privileges: [TRUNCATE]
+  billing_ro:
+    - on: sequences
+      in_schema: billing
+      privileges: [USAGE, SELECT]
+`
+
+	p, err := policy.Parse("p.yaml", []byte(in))
+	if err != nil {
+		t.Fatalf("unable to parse policy: %v", err)
+	}
+
+	inv := inventory()
+	objs, err := p.Expand(inv)
+	if err != nil {
+		t.Fatalf("unable to expand policy: %v", err)
+	}
+
+	if len(objs) != 0 {
+		t.Fatalf("bad: expected no objects, got %v", objs)
+	}
+
+	inv = append(inv,
+		acl.Object{Kind: acl.KindTable, Schema: "app", Name: "tmp_import", Owner: "owner"},
+		acl.Object{Kind: acl.KindSequence, Schema: "billing", Name: "invoices_id_seq", Owner: "owner"},
+	)
+
+	objs, err = p.Expand(inv)
+	if err != nil {
+		t.Fatalf("unable to expand policy: %v", err)
+	}
+
+	var got []string
+	for _, o := range objs {
+		got = append(got, o.Key()+" "+o.ACL[0].String())
+	}
+
+	want := []string{
+		`sequence:"billing"."invoices_id_seq" billing_ro=rU/owner`,
+		`table:"app"."tmp_import" cleaner=D/owner`,
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("bad: expected %v to equal %v", want, got)
+	}
+}
+
+func TestDefaultACLs(t *testing.T) {
+	const in = `version: 1
+roles:
+  app_ro:
+    - on: table
+      in_schema: [app, billing]
+      privileges: [SELECT]
+      defaults_for: app_owner
+    - on: sequence
+      in_schema: app
+      privileges: [USAGE]
+  PUBLIC:
+    - on: function
+      objects: ["app.*"]
+      privileges: [EXECUTE]
+      defaults_for: [app_owner]
+  app_rw:
+    - on: table
+      in_schema: app
+      privileges: [INSERT, SELECT]
+      with_grant_option: true
+      defaults_for: [app_owner]
+`
+
+	p, err := policy.Parse("app.yaml", []byte(in))
+	if err != nil {
+		t.Fatalf("unable to parse policy: %v", err)
+	}
+
+	got := make(map[string][]string)
+	for _, d := range p.DefaultACLs() {
+		for _, a := range d.ACL {
+			got[d.Key()] = append(got[d.Key()], a.String())
+		}
+	}
+
+	want := map[string][]string{
+		"default:function:role=app_owner":             {"=X/app_owner"},
+		"default:table:role=app_owner:schema=app":     {"app_ro=r/app_owner", "app_rw=a*r*/app_owner"},
+		"default:table:role=app_owner:schema=billing": {"app_ro=r/app_owner"},
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("bad: expected %v to equal %v", want, got)
+	}
+
+	if roles, want := p.Roles(), []string{"", "app_ro", "app_rw"}; !reflect.DeepEqual(want, roles) {
+		t.Fatalf("bad: expected %q to equal %q", want, roles)
+	}
+}
@@ -0,0 +1,409 @@
+// Package policy loads declarative descriptions of the privileges each role
+// should hold and expands them into desired ACL lists per object.
+//
+// Policies are written in YAML or JSON:
+//
+//	version: 1
+//	roles:
+//	  app_ro:
+//	    - on: schema
+//	      objects: [app]
+//	      privileges: [USAGE]
+//	    - on: table
+//	      in_schema: app
+//	      privileges: [SELECT]
+//	    - on: function
+//	      objects: ["app.report_*"]
+//	      privileges: [EXECUTE]
+//
+// Every rule grants its privileges to the role on the objects of one kind.
+// Objects are selected as described by ParseSelector, e.g. "app.accounts",
+// "app.tmp_%" or "~^app\.report_"; together with in_schema a bare name
+// pattern is matched within those schemas, and a rule with in_schema and no
+// objects applies to every object of its kind in those schemas.
+//
+// A rule with defaults_for also sets default privileges, so that objects
+// the listed roles create later, within the in_schema schemas or anywhere if
+// there are none, receive the rule's privileges too:
+//
+//	app_ro:
+//	  - on: table
+//	    in_schema: app
+//	    privileges: [SELECT]
+//	    defaults_for: [app_owner]
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	acl "github.com/sean-/postgresql-acl"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the policy format version understood by Parse.
+const Version = 1
+
+// Policy is a validated policy file.
+type Policy struct {
+	Rules []Rule
+}
+
+// Rule grants Privileges to Role on every object matched by Selectors.
+type Rule struct {
+	Role            string
+	Kind            acl.ObjectKind
+	Privileges      acl.Privileges
+	WithGrantOption bool
+	Selectors       []Selector
+
+	// Columns lists the columns a KindColumn rule applies to.
+	Columns []string
+
+	// Schemas lists the schemas named by in_schema.
+	Schemas []string
+
+	// DefaultsFor lists the roles whose future objects also receive the
+	// rule's privileges through ALTER DEFAULT PRIVILEGES.
+	DefaultsFor []string
+
+	// Pos is the location of the rule in the policy file.
+	Pos Position
+}
+
+// Position is a location in a policy file.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// Error is a problem found at a specific position in a policy file.
+type Error struct {
+	Pos Position
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Pos, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Load reads and parses the policy file at filename.
+func Load(filename string) (*Policy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(filename, data)
+}
+
+// Parse parses a YAML or JSON policy and validates every rule against the
+// privileges valid for its object kind.  filename is only used in error
+// messages.  All problems found are returned, joined with errors.Join, as
+// *Error values.
+func Parse(filename string, data []byte) (*Policy, error) {
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+		// JSON may be indented with tabs, which YAML does not allow.  Raw
+		// tabs can only appear as whitespace in valid JSON.
+		data = []byte(strings.ReplaceAll(string(data), "\t", " "))
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, &Error{Pos: Position{File: filename}, Err: err}
+	}
+
+	p := &parser{file: filename}
+	policy := p.parse(&root)
+	if len(p.errs) > 0 {
+		return nil, errors.Join(p.errs...)
+	}
+
+	return policy, nil
+}
+
+// parser accumulates the errors found while walking the YAML document.
+type parser struct {
+	file string
+	errs []error
+}
+
+func (p *parser) errorf(n *yaml.Node, format string, args ...interface{}) {
+	p.errs = append(p.errs, &Error{
+		Pos: p.pos(n),
+		Err: fmt.Errorf(format, args...),
+	})
+}
+
+func (p *parser) pos(n *yaml.Node) Position {
+	return Position{File: p.file, Line: n.Line, Column: n.Column}
+}
+
+func (p *parser) parse(root *yaml.Node) *Policy {
+	policy := &Policy{}
+	if root.Kind == 0 {
+		p.errorf(root, "empty policy")
+		return policy
+	}
+
+	doc := root
+	if doc.Kind == yaml.DocumentNode {
+		doc = doc.Content[0]
+	}
+
+	fields := p.mapping(doc, "version", "roles")
+	if fields == nil {
+		return policy
+	}
+
+	if v := fields["version"]; v == nil {
+		p.errorf(doc, "missing version")
+	} else {
+		var version int
+		if err := v.Decode(&version); err != nil || version != Version {
+			p.errorf(v, "unsupported policy version %+q, only version %d is supported", v.Value, Version)
+		}
+	}
+
+	roles := fields["roles"]
+	if roles == nil {
+		return policy
+	}
+
+	if roles.Kind != yaml.MappingNode {
+		p.errorf(roles, "roles must be a mapping of role names to rules")
+		return policy
+	}
+
+	for i := 0; i+1 < len(roles.Content); i += 2 {
+		name, rules := roles.Content[i], roles.Content[i+1]
+		if rules.Kind != yaml.SequenceNode {
+			p.errorf(rules, "rules for role %+q must be a list", name.Value)
+			continue
+		}
+
+		for _, r := range rules.Content {
+			if rule, ok := p.rule(name.Value, r); ok {
+				policy.Rules = append(policy.Rules, rule)
+			}
+		}
+	}
+
+	return policy
+}
+
+// mapping checks that n is a mapping containing only the allowed keys and
+// returns its values by key.
+func (p *parser) mapping(n *yaml.Node, allowed ...string) map[string]*yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		p.errorf(n, "expected a mapping")
+		return nil
+	}
+
+	fields := make(map[string]*yaml.Node, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+
+		known := false
+		for _, a := range allowed {
+			if key.Value == a {
+				known = true
+				break
+			}
+		}
+
+		switch {
+		case !known:
+			p.errorf(key, "unknown field %+q", key.Value)
+		case fields[key.Value] != nil:
+			p.errorf(key, "duplicate field %+q", key.Value)
+		default:
+			fields[key.Value] = value
+		}
+	}
+
+	return fields
+}
+
+// stringList decodes a scalar or a list of scalars.
+func (p *parser) stringList(n *yaml.Node) []string {
+	if n == nil {
+		return nil
+	}
+
+	if n.Kind == yaml.ScalarNode {
+		return []string{n.Value}
+	}
+
+	var values []string
+	if err := n.Decode(&values); err != nil {
+		p.errorf(n, "expected a string or a list of strings")
+		return nil
+	}
+
+	return values
+}
+
+func (p *parser) rule(role string, n *yaml.Node) (Rule, bool) {
+	nerrs := len(p.errs)
+	rule := Rule{Role: role, Pos: p.pos(n)}
+
+	fields := p.mapping(n, "on", "objects", "in_schema", "columns", "privileges", "with_grant_option", "defaults_for")
+	if fields == nil {
+		return Rule{}, false
+	}
+
+	on := fields["on"]
+	if on == nil {
+		p.errorf(n, "missing field %+q", "on")
+		return Rule{}, false
+	}
+
+	kind, err := parseKind(on.Value)
+	if err != nil {
+		p.errorf(on, "%v", err)
+		return Rule{}, false
+	}
+	rule.Kind = kind
+
+	objects := p.stringList(fields["objects"])
+	schemas := p.stringList(fields["in_schema"])
+	rule.Schemas = schemas
+	rule.Columns = p.stringList(fields["columns"])
+	rule.DefaultsFor = p.stringList(fields["defaults_for"])
+
+	switch {
+	case len(objects) == 0 && len(schemas) == 0:
+		p.errorf(n, "rule must specify objects or in_schema")
+	case !schemaScoped(kind) && len(schemas) > 0:
+		p.errorf(fields["in_schema"], "in_schema is not valid for %s rules", kind)
+	}
+
+	if kind == acl.KindColumn && len(rule.Columns) == 0 {
+		p.errorf(n, "column rules must specify columns")
+	}
+
+	if kind != acl.KindColumn && len(rule.Columns) > 0 {
+		p.errorf(fields["columns"], "columns are only valid for column rules")
+	}
+
+	if len(rule.DefaultsFor) > 0 {
+		if err := (acl.DefaultACL{Role: rule.DefaultsFor[0], Kind: kind}).Validate(); err != nil {
+			p.errorf(fields["defaults_for"], "defaults_for is not valid for %s rules", kind)
+		}
+	}
+
+	if len(objects) == 0 {
+		for _, schema := range schemas {
+			sel, err := NewSelector(kind, schema, "")
+			if err != nil {
+				p.errorf(fields["in_schema"], "%v", err)
+				continue
+			}
+			rule.Selectors = append(rule.Selectors, sel)
+		}
+	}
+
+	for _, o := range objects {
+		sel, err := ParseSelector(kind, o)
+		if err != nil {
+			p.errorf(fields["objects"], "%v", err)
+			continue
+		}
+
+		if sel.Regexp != nil || sel.Schema != "" || len(schemas) == 0 {
+			rule.Selectors = append(rule.Selectors, sel)
+			continue
+		}
+
+		for _, schema := range schemas {
+			scoped := sel
+			scoped.Schema = schema
+			if err := scoped.compile(); err != nil {
+				p.errorf(fields["in_schema"], "%v", err)
+				continue
+			}
+			rule.Selectors = append(rule.Selectors, scoped)
+		}
+	}
+
+	privs := fields["privileges"]
+	if privs == nil {
+		p.errorf(n, "missing field %+q", "privileges")
+	} else {
+		rule.Privileges = p.privileges(kind, privs)
+	}
+
+	if g := fields["with_grant_option"]; g != nil {
+		if err := g.Decode(&rule.WithGrantOption); err != nil {
+			p.errorf(g, "with_grant_option must be true or false")
+		}
+	}
+
+	return rule, len(p.errs) == nerrs
+}
+
+// privileges parses the privilege list of a rule and checks it against the
+// privileges valid for kind.  ALL expands to every valid privilege.
+func (p *parser) privileges(kind acl.ObjectKind, n *yaml.Node) acl.Privileges {
+	valid := kind.ValidPrivileges()
+	nerrs := len(p.errs)
+
+	var privs acl.Privileges
+	for _, name := range p.stringList(n) {
+		if u := strings.ToUpper(strings.TrimSpace(name)); u == "ALL" || u == "ALL PRIVILEGES" {
+			privs |= valid
+			continue
+		}
+
+		priv, err := acl.ParsePrivilege(name)
+		if err != nil {
+			p.errorf(n, "%v", err)
+			continue
+		}
+
+		if priv&valid == 0 {
+			p.errorf(n, "privilege %s is not valid for %s, only %s allowed", priv, kind, valid)
+			continue
+		}
+
+		privs |= priv
+	}
+
+	if privs == acl.NoPrivs && len(p.errs) == nerrs {
+		p.errorf(n, "rule grants no privileges")
+	}
+
+	return privs
+}
+
+// parseKind accepts an object kind in singular or plural form.
+func parseKind(s string) (acl.ObjectKind, error) {
+	s = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), " ", "_")
+	if kind, err := acl.ParseObjectKind(s); err == nil {
+		return kind, nil
+	}
+
+	return acl.ParseObjectKind(strings.TrimSuffix(s, "s"))
+}
+
+// schemaScoped returns true for the kinds whose objects live in a schema.
+func schemaScoped(kind acl.ObjectKind) bool {
+	switch kind {
+	case acl.KindColumn, acl.KindDomain, acl.KindFunction, acl.KindProcedure, acl.KindSequence, acl.KindTable, acl.KindType:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,249 @@
+package policy_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+	"github.com/sean-/postgresql-acl/policy"
+)
+
+const appPolicy = `version: 1
+roles:
+  app_ro:
+    - on: schema
+      objects: [app]
+      privileges: [USAGE]
+    - on: tables
+      in_schema: app
+      privileges: [select]
+    - on: function
+      objects: ["app.report_*"]
+      privileges: EXECUTE
+  app_rw:
+    - on: table
+      objects: [app.accounts, app.ledger]
+      privileges: [ALL]
+      with_grant_option: true
+    - on: column
+      objects: [app.users]
+      columns: [email]
+      privileges: [SELECT, UPDATE]
+`
+
+// rule is a policy.Rule with its selectors in string form, which can be
+// compared with reflect.DeepEqual.
+type rule struct {
+	Role            string
+	Kind            acl.ObjectKind
+	Privileges      acl.Privileges
+	WithGrantOption bool
+	Selectors       []string
+	Columns         []string
+	Pos             policy.Position
+}
+
+func rules(p *policy.Policy) []rule {
+	var rs []rule
+	for _, r := range p.Rules {
+		var sels []string
+		for _, s := range r.Selectors {
+			sels = append(sels, s.String())
+		}
+
+		rs = append(rs, rule{
+			Role:            r.Role,
+			Kind:            r.Kind,
+			Privileges:      r.Privileges,
+			WithGrantOption: r.WithGrantOption,
+			Selectors:       sels,
+			Columns:         r.Columns,
+			Pos:             r.Pos,
+		})
+	}
+
+	return rs
+}
+
+func TestParse(t *testing.T) {
+	p, err := policy.Parse("app.yaml", []byte(appPolicy))
+	if err != nil {
+		t.Fatalf("unable to parse policy: %v", err)
+	}
+
+	want := []rule{
+		{
+			Role:       "app_ro",
+			Kind:       acl.KindSchema,
+			Privileges: acl.Usage,
+			Selectors:  []string{"app"},
+			Pos:        policy.Position{File: "app.yaml", Line: 4, Column: 7},
+		},
+		{
+			Role:       "app_ro",
+			Kind:       acl.KindTable,
+			Privileges: acl.Select,
+			Selectors:  []string{"app.*"},
+			Pos:        policy.Position{File: "app.yaml", Line: 7, Column: 7},
+		},
+		{
+			Role:       "app_ro",
+			Kind:       acl.KindFunction,
+			Privileges: acl.Execute,
+			Selectors:  []string{"app.report_*"},
+			Pos:        policy.Position{File: "app.yaml", Line: 10, Column: 7},
+		},
+		{
+			Role:            "app_rw",
+			Kind:            acl.KindTable,
+			Privileges:      acl.Insert | acl.Select | acl.Update | acl.Delete | acl.Truncate | acl.References | acl.Trigger,
+			WithGrantOption: true,
+			Selectors:       []string{"app.accounts", "app.ledger"},
+			Pos:             policy.Position{File: "app.yaml", Line: 14, Column: 7},
+		},
+		{
+			Role:       "app_rw",
+			Kind:       acl.KindColumn,
+			Privileges: acl.Select | acl.Update,
+			Selectors:  []string{"app.users"},
+			Columns:    []string{"email"},
+			Pos:        policy.Position{File: "app.yaml", Line: 18, Column: 7},
+		},
+	}
+
+	if got := rules(p); !reflect.DeepEqual(want, got) {
+		t.Fatalf("bad: expected %+v to equal %+v", want, got)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	const in = "{\n\t\"version\": 1,\n\t\"roles\": {\n\t\t\"app_ro\": [\n\t\t\t{\"on\": \"schema\", \"objects\": [\"app\"], \"privileges\": [\"USAGE\"]}\n\t\t]\n\t}\n}\n"
+
+	p, err := policy.Parse("app.json", []byte(in))
+	if err != nil {
+		t.Fatalf("unable to parse policy: %v", err)
+	}
+
+	want := []rule{{
+		Role:       "app_ro",
+		Kind:       acl.KindSchema,
+		Privileges: acl.Usage,
+		Selectors:  []string{"app"},
+		Pos:        policy.Position{File: "app.json", Line: 5, Column: 4},
+	}}
+
+	if got := rules(p); !reflect.DeepEqual(want, got) {
+		t.Fatalf("bad: expected %+v to equal %+v", want, got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		errs []string
+	}{
+		{
+			name: "missing version",
+			in:   "roles: {}\n",
+			errs: []string{"p.yaml:1:1: missing version"},
+		},
+		{
+			name: "unsupported version",
+			in:   "version: 2\n",
+			errs: []string{`p.yaml:1:10: unsupported policy version "2", only version 1 is supported`},
+		},
+		{
+			name: "invalid privilege for kind",
+			in: `version: 1
+roles:
+  app_ro:
+    - on: schema
+      objects: [app]
+      privileges: [USAGE, SELECT]
+`,
+			errs: []string{"p.yaml:6:19: privilege SELECT is not valid for schema, only CREATE, USAGE allowed"},
+		},
+		{
+			name: "every error is reported",
+			in: `version: 1
+roles:
+  app_ro:
+    - on: widget
+      objects: [app]
+      privileges: [USAGE]
+    - on: sequence
+      in_schema: app
+      privileges: [EXECUTE, FLY]
+    - on: database
+      in_schema: app
+      privileges: [CONNECT]
+      colour: blue
+`,
+			errs: []string{
+				`p.yaml:4:11: unknown object kind "widget"`,
+				"p.yaml:9:19: privilege EXECUTE is not valid for sequence, only SELECT, UPDATE, USAGE allowed",
+				`p.yaml:9:19: unknown privilege "FLY"`,
+				`p.yaml:13:7: unknown field "colour"`,
+				"p.yaml:11:18: in_schema is not valid for database rules",
+			},
+		},
+		{
+			name: "missing objects",
+			in: `version: 1
+roles:
+  app_ro:
+    - on: table
+      privileges: [SELECT]
+`,
+			errs: []string{"p.yaml:4:7: rule must specify objects or in_schema"},
+		},
+		{
+			name: "column rule without columns",
+			in: `version: 1
+roles:
+  app_ro:
+    - on: column
+      objects: [app.users]
+      privileges: [SELECT]
+`,
+			errs: []string{"p.yaml:4:7: column rules must specify columns"},
+		},
+		{
+			name: "defaults for unsupported kind",
+			in: `version: 1
+roles:
+  app_ro:
+    - on: database
+      objects: [appdb]
+      privileges: [CONNECT]
+      defaults_for: [app_owner]
+`,
+			errs: []string{"p.yaml:7:21: defaults_for is not valid for database rules"},
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			_, err := policy.Parse("p.yaml", []byte(test.in))
+			if err == nil {
+				t.Fatalf("expected failure")
+			}
+
+			var perr *policy.Error
+			if !errors.As(err, &perr) {
+				t.Fatalf("bad: expected a *policy.Error, got %T", err)
+			}
+
+			if got := strings.Split(err.Error(), "\n"); !reflect.DeepEqual(test.errs, got) {
+				t.Fatalf("bad: expected %q to equal %q", test.errs, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// Selector matches objects in an inventory by kind, schema and name.
+//
+// Schema and name patterns are either globs (*, ? and [...] as understood by
+// path.Match) or, if they contain a %, SQL LIKE patterns where % matches any
+// string and _ matches any single character.  An empty pattern matches
+// everything.  A selector may instead carry a regular expression, which is
+// matched against the unquoted "schema.name" of schema-qualified objects and
+// against the name of all others.
+type Selector struct {
+	Kind acl.ObjectKind
+
+	Schema string
+	Name   string
+
+	// Signature restricts function and procedure selectors to one overload,
+	// e.g. "integer".  It is ignored when HasSignature is false.
+	Signature    string
+	HasSignature bool
+
+	Regexp *regexp.Regexp
+
+	schema *regexp.Regexp
+	name   *regexp.Regexp
+}
+
+// NewSelector returns a selector for objects of kind whose schema and name
+// match the given patterns.
+func NewSelector(kind acl.ObjectKind, schema, name string) (Selector, error) {
+	s := Selector{Kind: kind, Schema: schema, Name: name}
+	if err := s.compile(); err != nil {
+		return Selector{}, err
+	}
+
+	return s, nil
+}
+
+// ParseSelector parses an object reference as written in a policy file:
+//
+//	app.accounts            one table
+//	app.*  or  app.%        everything of kind in schema app
+//	app.tmp_%               LIKE pattern
+//	app.report(integer)     one function overload
+//	~^app\.tmp_[0-9]+$      regular expression
+//
+// For kinds that do not live in a schema the whole reference is the name
+// pattern.  Unqualified references of schema-scoped kinds match in any schema.
+func ParseSelector(kind acl.ObjectKind, ref string) (Selector, error) {
+	if re, ok := strings.CutPrefix(ref, "~"); ok {
+		compiled, err := regexp.Compile(re)
+		if err != nil {
+			return Selector{}, fmt.Errorf("invalid regular expression %+q: %w", re, err)
+		}
+
+		return Selector{Kind: kind, Regexp: compiled}, nil
+	}
+
+	if !schemaScoped(kind) {
+		return NewSelector(kind, "", ref)
+	}
+
+	schema, name, ok := strings.Cut(ref, ".")
+	if !ok {
+		schema, name = "", ref
+	}
+
+	var sig string
+	var hasSig bool
+	if routine(kind) {
+		if fn, args, ok := strings.Cut(name, "("); ok {
+			name, sig, hasSig = fn, strings.TrimSuffix(args, ")"), true
+		}
+	}
+
+	s, err := NewSelector(kind, schema, name)
+	if err != nil {
+		return Selector{}, err
+	}
+	s.Signature, s.HasSignature = sig, hasSig
+
+	return s, nil
+}
+
+// Exact reports whether the selector names a single object rather than a
+// pattern, so a caller can report a missing object instead of an empty match.
+func (s Selector) Exact() bool {
+	return s.Regexp == nil && s.Name != "" && !isPattern(s.Name) &&
+		(!schemaScoped(s.Kind) || (s.Schema != "" && !isPattern(s.Schema))) &&
+		(!routine(s.Kind) || s.HasSignature)
+}
+
+// String returns the selector in the form accepted by ParseSelector.
+func (s Selector) String() string {
+	if s.Regexp != nil {
+		return "~" + s.Regexp.String()
+	}
+
+	ref := s.Name
+	if ref == "" {
+		ref = "*"
+	}
+
+	if s.Schema != "" {
+		ref = s.Schema + "." + ref
+	}
+
+	if s.HasSignature {
+		ref += "(" + s.Signature + ")"
+	}
+
+	return ref
+}
+
+// Match reports whether obj is selected.  Column selectors match the
+// relations whose columns they select.
+func (s Selector) Match(obj acl.Object) bool {
+	kind := s.Kind
+	if kind == acl.KindColumn {
+		kind = acl.KindTable
+	}
+
+	if obj.Kind != kind {
+		return false
+	}
+
+	name := obj.Name
+	if obj.Kind == acl.KindLargeObject {
+		name = strconv.FormatUint(uint64(obj.OID), 10)
+	}
+
+	if s.Regexp != nil {
+		if obj.Schema != "" {
+			name = obj.Schema + "." + name
+		}
+
+		return s.Regexp.MatchString(name)
+	}
+
+	if s.schema == nil && s.name == nil {
+		// Built as a literal rather than with NewSelector.
+		if err := s.compile(); err != nil {
+			return false
+		}
+	}
+
+	if s.HasSignature && obj.Signature != s.Signature {
+		return false
+	}
+
+	return s.schema.MatchString(obj.Schema) && s.name.MatchString(name)
+}
+
+// Resolve returns the objects in inventory matched by the selector.
+func (s Selector) Resolve(inventory []acl.Object) []acl.Object {
+	var objs []acl.Object
+	for _, obj := range inventory {
+		if s.Match(obj) {
+			objs = append(objs, obj)
+		}
+	}
+
+	return objs
+}
+
+func (s *Selector) compile() error {
+	var err error
+	if s.schema, err = compilePattern(s.Schema); err != nil {
+		return err
+	}
+	if s.name, err = compilePattern(s.Name); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// compilePattern translates a glob or LIKE pattern into an anchored regular
+// expression.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return regexp.MustCompile(""), nil
+	}
+
+	like := strings.Contains(pattern, "%")
+
+	b := new(strings.Builder)
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case like && c == '%':
+			b.WriteString(".*")
+		case like && c == '_':
+			b.WriteString(".")
+		case !like && c == '*':
+			b.WriteString(".*")
+		case !like && c == '?':
+			b.WriteString(".")
+		case !like && c == '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid pattern %+q: unterminated [", pattern)
+			}
+
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			fmt.Fprintf(b, "[%s]", class)
+			i += end
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %+q: %w", pattern, err)
+	}
+
+	return re, nil
+}
+
+func isPattern(s string) bool {
+	return strings.ContainsAny(s, `*?[%\`)
+}
+
+// routine returns true for the kinds whose objects are identified by their
+// argument types as well as their name.
+func routine(kind acl.ObjectKind) bool {
+	return kind == acl.KindFunction || kind == acl.KindProcedure
+}
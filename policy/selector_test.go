@@ -0,0 +1,175 @@
+package policy_test
+
+import (
+	"reflect"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+	"github.com/sean-/postgresql-acl/policy"
+)
+
+func TestSelectorMatch(t *testing.T) {
+	objs := []acl.Object{
+		{Kind: acl.KindSchema, Name: "app"},
+		{Kind: acl.KindTable, Schema: "app", Name: "accounts"},
+		{Kind: acl.KindTable, Schema: "app", Name: "tmp_1"},
+		{Kind: acl.KindTable, Schema: "app", Name: "tmp_import"},
+		{Kind: acl.KindTable, Schema: "billing", Name: "invoices"},
+		{Kind: acl.KindSequence, Schema: "billing", Name: "invoices_id_seq"},
+		{Kind: acl.KindSequence, Schema: "billing", Name: "payments_id_seq"},
+		{Kind: acl.KindFunction, Schema: "app", Name: "report", Signature: "integer"},
+		{Kind: acl.KindFunction, Schema: "app", Name: "report", Signature: "text"},
+		{Kind: acl.KindLargeObject, OID: 16440},
+	}
+
+	tests := []struct {
+		name  string
+		kind  acl.ObjectKind
+		ref   string
+		keys  []string
+		exact bool
+		fail  bool
+	}{
+		{
+			name:  "exact table",
+			kind:  acl.KindTable,
+			ref:   "app.accounts",
+			keys:  []string{`table:"app"."accounts"`},
+			exact: true,
+		},
+		{
+			name: "glob",
+			kind: acl.KindTable,
+			ref:  "app.*",
+			keys: []string{`table:"app"."accounts"`, `table:"app"."tmp_1"`, `table:"app"."tmp_import"`},
+		},
+		{
+			name: "like",
+			kind: acl.KindTable,
+			ref:  "app.tmp_%",
+			keys: []string{`table:"app"."tmp_1"`, `table:"app"."tmp_import"`},
+		},
+		{
+			name: "like underscore is a wildcard",
+			kind: acl.KindTable,
+			ref:  "%.accoun_s%",
+			keys: []string{`table:"app"."accounts"`},
+		},
+		{
+			name: "glob underscore is literal",
+			kind: acl.KindTable,
+			ref:  "*.tmp_?",
+			keys: []string{`table:"app"."tmp_1"`},
+		},
+		{
+			name: "all sequences in schema",
+			kind: acl.KindSequence,
+			ref:  "billing.*",
+			keys: []string{`sequence:"billing"."invoices_id_seq"`, `sequence:"billing"."payments_id_seq"`},
+		},
+		{
+			name: "unqualified name in any schema",
+			kind: acl.KindTable,
+			ref:  "invoices",
+			keys: []string{`table:"billing"."invoices"`},
+		},
+		{
+			name: "regexp",
+			kind: acl.KindTable,
+			ref:  `~^app\.tmp_[0-9]+$`,
+			keys: []string{`table:"app"."tmp_1"`},
+		},
+		{
+			name: "character class",
+			kind: acl.KindSequence,
+			ref:  "billing.[!i]*",
+			keys: []string{`sequence:"billing"."payments_id_seq"`},
+		},
+		{
+			name: "function overloads",
+			kind: acl.KindFunction,
+			ref:  "app.report",
+			keys: []string{`function:"app"."report"(integer)`, `function:"app"."report"(text)`},
+		},
+		{
+			name:  "function signature",
+			kind:  acl.KindFunction,
+			ref:   "app.report(text)",
+			keys:  []string{`function:"app"."report"(text)`},
+			exact: true,
+		},
+		{
+			name:  "schema",
+			kind:  acl.KindSchema,
+			ref:   "app",
+			keys:  []string{`schema:"app"`},
+			exact: true,
+		},
+		{
+			name:  "large object",
+			kind:  acl.KindLargeObject,
+			ref:   "16440",
+			keys:  []string{`large_object:16440`},
+			exact: true,
+		},
+		{
+			name: "bad regexp",
+			kind: acl.KindTable,
+			ref:  "~(",
+			fail: true,
+		},
+		{
+			name: "bad glob",
+			kind: acl.KindTable,
+			ref:  "app.[a",
+			fail: true,
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			sel, err := policy.ParseSelector(test.kind, test.ref)
+			if err != nil && !test.fail {
+				t.Fatalf("unable to parse selector %+q: %v", test.ref, err)
+			}
+
+			if err == nil && test.fail {
+				t.Fatalf("expected failure")
+			}
+
+			if test.fail {
+				return
+			}
+
+			var keys []string
+			for _, o := range sel.Resolve(objs) {
+				keys = append(keys, o.Key())
+			}
+
+			if !reflect.DeepEqual(test.keys, keys) {
+				t.Fatalf("bad: expected %v to equal %v", test.keys, keys)
+			}
+
+			if sel.Exact() != test.exact {
+				t.Fatalf("bad: expected Exact() to be %t", test.exact)
+			}
+
+			if s := sel.String(); s != test.ref {
+				t.Fatalf("want %+q got %+q", test.ref, s)
+			}
+		})
+	}
+}
+
+func TestSelectorLiteral(t *testing.T) {
+	sel := policy.Selector{Kind: acl.KindTable, Schema: "app"}
+	obj := acl.Object{Kind: acl.KindTable, Schema: "app", Name: "accounts"}
+
+	if !sel.Match(obj) {
+		t.Fatalf("bad: expected %v to match %v", sel, obj)
+	}
+}
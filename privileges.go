@@ -1,5 +1,12 @@
 package acl
 
+import (
+	"fmt"
+	"iter"
+	"math/bits"
+	"strings"
+)
+
 // Privileges represents a PostgreSQL ACL bitmask
 type Privileges uint16
 
@@ -23,6 +30,9 @@ const (
 	Connect
 
 	numPrivileges
+
+	// allPrivileges holds every privilege bit.
+	allPrivileges = numPrivileges - Insert
 )
 
 const (
@@ -40,3 +50,107 @@ const (
 	validTablespacePrivs         = Create
 	validTypePrivs               = Usage
 )
+
+// privilegeNames maps each privilege to its SQL keyword.  Entries are sorted
+// by keyword, which is the order in which statements are generated.
+var privilegeNames = []struct {
+	priv Privileges
+	name string
+}{
+	{Connect, "CONNECT"},
+	{Create, "CREATE"},
+	{Delete, "DELETE"},
+	{Execute, "EXECUTE"},
+	{Insert, "INSERT"},
+	{References, "REFERENCES"},
+	{Select, "SELECT"},
+	{Temporary, "TEMPORARY"},
+	{Trigger, "TRIGGER"},
+	{Truncate, "TRUNCATE"},
+	{Update, "UPDATE"},
+	{Usage, "USAGE"},
+}
+
+// privilegeList returns the SQL keywords of the privileges set in p.
+func privilegeList(p Privileges) []string {
+	var names []string
+	for _, n := range privilegeNames {
+		if p&n.priv != 0 {
+			names = append(names, n.name)
+		}
+	}
+
+	return names
+}
+
+// Names returns the SQL keywords of the privileges set in p, sorted
+// alphabetically.
+func (p Privileges) Names() []string {
+	return privilegeList(p)
+}
+
+// String returns the SQL keywords of the privileges set in p separated by
+// commas, e.g. "INSERT, SELECT".
+func (p Privileges) String() string {
+	return strings.Join(privilegeList(p), ", ")
+}
+
+// ParsePrivilege returns the privilege named by its SQL keyword, e.g.
+// "SELECT".  Matching is case-insensitive and TEMP is accepted as an alias
+// for TEMPORARY.
+func ParsePrivilege(name string) (Privileges, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "TEMP" {
+		name = "TEMPORARY"
+	}
+
+	for _, n := range privilegeNames {
+		if n.name == name {
+			return n.priv, nil
+		}
+	}
+
+	return NoPrivs, fmt.Errorf("unknown privilege %+q", name)
+}
+
+// Union returns the privileges in p or o.
+func (p Privileges) Union(o Privileges) Privileges {
+	return p | o
+}
+
+// Intersect returns the privileges in both p and o.
+func (p Privileges) Intersect(o Privileges) Privileges {
+	return p & o
+}
+
+// Without returns the privileges in p that are not in o.
+func (p Privileges) Without(o Privileges) Privileges {
+	return p &^ o
+}
+
+// Contains reports whether p holds every privilege in o.
+func (p Privileges) Contains(o Privileges) bool {
+	return p&o == o
+}
+
+// IsSubsetOf reports whether every privilege in p is also in o.
+func (p Privileges) IsSubsetOf(o Privileges) bool {
+	return o.Contains(p)
+}
+
+// Len returns the number of privileges in p.
+func (p Privileges) Len() int {
+	return bits.OnesCount16(uint16(p))
+}
+
+// All returns an iterator over the privileges in p, one at a time, in the
+// order of PostgreSQL's privilege bits, e.g. INSERT before SELECT.
+func (p Privileges) All() iter.Seq[Privileges] {
+	return func(yield func(Privileges) bool) {
+		for priv := Insert; priv < numPrivileges; priv <<= 1 {
+			if p&priv != 0 && !yield(priv) {
+				return
+			}
+		}
+	}
+}
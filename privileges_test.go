@@ -0,0 +1,138 @@
+package acl_test
+
+import (
+	"reflect"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+func TestPrivilegeNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		privs acl.Privileges
+		names []string
+		str   string
+	}{
+		{
+			name:  "none",
+			privs: acl.NoPrivs,
+			str:   "",
+		},
+		{
+			name:  "schema",
+			privs: acl.Usage | acl.Create,
+			names: []string{"CREATE", "USAGE"},
+			str:   "CREATE, USAGE",
+		},
+		{
+			name:  "table",
+			privs: acl.Insert | acl.Select | acl.Update | acl.Delete | acl.Truncate | acl.References | acl.Trigger,
+			names: []string{"DELETE", "INSERT", "REFERENCES", "SELECT", "TRIGGER", "TRUNCATE", "UPDATE"},
+			str:   "DELETE, INSERT, REFERENCES, SELECT, TRIGGER, TRUNCATE, UPDATE",
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			if names := test.privs.Names(); !reflect.DeepEqual(test.names, names) {
+				t.Fatalf("bad: expected %v to equal %v", test.names, names)
+			}
+
+			if str := test.privs.String(); str != test.str {
+				t.Fatalf("want %+q got %+q", test.str, str)
+			}
+
+			for _, name := range test.names {
+				priv, err := acl.ParsePrivilege(name)
+				if err != nil {
+					t.Fatalf("unable to parse privilege %+q: %v", name, err)
+				}
+
+				if priv&test.privs == 0 {
+					t.Fatalf("bad: %+q not in %v", name, test.privs)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePrivilege(t *testing.T) {
+	tests := []struct {
+		in   string
+		want acl.Privileges
+		fail bool
+	}{
+		{in: "SELECT", want: acl.Select},
+		{in: "select", want: acl.Select},
+		{in: " Usage ", want: acl.Usage},
+		{in: "TEMP", want: acl.Temporary},
+		{in: "TEMPORARY", want: acl.Temporary},
+		{in: "ALL", fail: true},
+		{in: "r", fail: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			got, err := acl.ParsePrivilege(test.in)
+			if err != nil && !test.fail {
+				t.Fatalf("unable to parse privilege %+q: %v", test.in, err)
+			}
+
+			if err == nil && test.fail {
+				t.Fatalf("expected failure")
+			}
+
+			if got != test.want {
+				t.Fatalf("bad: expected %v to equal %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestPrivilegeSets(t *testing.T) {
+	const (
+		a = acl.Select | acl.Insert | acl.Update
+		b = acl.Update | acl.Delete
+	)
+
+	if got, want := a.Union(b), acl.Select|acl.Insert|acl.Update|acl.Delete; got != want {
+		t.Fatalf("bad: expected %v to equal %v", got, want)
+	}
+	if got, want := a.Intersect(b), acl.Update; got != want {
+		t.Fatalf("bad: expected %v to equal %v", got, want)
+	}
+	if got, want := a.Without(b), acl.Select|acl.Insert; got != want {
+		t.Fatalf("bad: expected %v to equal %v", got, want)
+	}
+
+	if !a.Contains(acl.Select|acl.Update) || a.Contains(b) || !a.Contains(acl.NoPrivs) {
+		t.Fatalf("bad: unexpected Contains result for %v", a)
+	}
+	if !acl.Update.IsSubsetOf(a) || b.IsSubsetOf(a) {
+		t.Fatalf("bad: unexpected IsSubsetOf result for %v", a)
+	}
+
+	if a.Len() != 3 || acl.NoPrivs.Len() != 0 {
+		t.Fatalf("bad: unexpected Len %d", a.Len())
+	}
+
+	var got []acl.Privileges
+	for p := range a.All() {
+		got = append(got, p)
+	}
+	if want := []acl.Privileges{acl.Insert, acl.Select, acl.Update}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("bad: expected %v to equal %v", got, want)
+	}
+
+	for p := range a.All() {
+		if p != acl.Insert {
+			t.Fatalf("bad: iteration continued after break with %v", p)
+		}
+		break
+	}
+}
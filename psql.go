@@ -0,0 +1,352 @@
+package acl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// psqlRelationTypes maps the Type column of psql's \dp to object kinds.
+var psqlRelationTypes = map[string]ObjectKind{
+	"foreign table":     KindTable,
+	"materialized view": KindTable,
+	"partitioned table": KindTable,
+	"sequence":          KindSequence,
+	"table":             KindTable,
+	"view":              KindTable,
+}
+
+// psqlDefaultTypes maps the Type column of psql's \ddp to object kinds.
+var psqlDefaultTypes = map[string]ObjectKind{
+	"function":     KindFunction,
+	"large object": KindLargeObject,
+	"schema":       KindSchema,
+	"sequence":     KindSequence,
+	"table":        KindTable,
+	"type":         KindType,
+}
+
+// psqlRecord is one row of a psql table with its cells joined across lines.
+type psqlRecord struct {
+	line  int
+	cells []string
+}
+
+// ReadAccessPrivileges reads the output of psql's \dp (or \z) and \ddp
+// meta-commands, e.g. as pasted into a support ticket, and returns the
+// objects and default privileges it lists with their ACLs parsed by Parse.
+// Both the aligned format, with multi-line and wrapped cells, and the
+// unaligned format of psql -A are read, and several tables may follow one
+// another with other text between them.  Tables without an "Access
+// privileges" column, or with one but other columns than \dp and \ddp, are
+// skipped.
+//
+// \dp shows no owners, so objects are returned without one.  An empty Access
+// privileges cell stands for the built-in privileges of the unknown owner and
+// is returned as a nil ACL, while "(none)" is returned as an empty one.
+// Column privileges are returned as KindColumn objects.
+//
+// Rows that cannot be parsed are reported as *ScriptError values joined with
+// errors.Join, alongside the rows that could.
+func ReadAccessPrivileges(r io.Reader) (*Dump, error) {
+	var lines []string
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		lines = append(lines, strings.TrimRight(s.Text(), "\r"))
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read psql output: %w", err)
+	}
+
+	d := &Dump{}
+	var errs []error
+	for i := 0; i < len(lines); i++ {
+		var header []string
+		var records []psqlRecord
+		switch {
+		case i+1 < len(lines) && isPsqlRule(lines[i+1]):
+			header = psqlCells(lines[i], strings.HasPrefix(lines[i+1], "+"))
+			if !isPrivilegeTable(header) {
+				continue
+			}
+			records, i = readAligned(lines, i+2, len(header), strings.HasPrefix(lines[i+1], "+"))
+		case strings.Contains(lines[i], "|"):
+			header = strings.Split(lines[i], "|")
+			if !isPrivilegeTable(header) {
+				continue
+			}
+			records, i = readUnaligned(lines, i+1, len(header))
+		default:
+			continue
+		}
+
+		for _, rec := range records {
+			if err := d.record(header, rec); err != nil {
+				errs = append(errs, &ScriptError{Line: rec.line, Err: err})
+			}
+		}
+	}
+
+	return d, errors.Join(errs...)
+}
+
+// record adds the objects or default privileges of one \dp or \ddp row.
+func (d *Dump) record(header []string, rec psqlRecord) error {
+	cell := func(name string) string {
+		for i, h := range header {
+			if strings.TrimSpace(h) == name {
+				return rec.cells[i]
+			}
+		}
+
+		return ""
+	}
+
+	if len(rec.cells) != len(header) {
+		return fmt.Errorf("expected %d columns, got %d", len(header), len(rec.cells))
+	}
+
+	if !hasColumn(header, "Name") {
+		kind, ok := psqlDefaultTypes[cell("Type")]
+		if !ok {
+			return fmt.Errorf("unknown default privilege type %+q", cell("Type"))
+		}
+
+		def := DefaultACL{Role: cell("Owner"), Schema: cell("Schema"), Kind: kind}
+		var err error
+		if def.ACL, err = psqlACL(cell("Access privileges")); err != nil {
+			return err
+		}
+		if err := def.Validate(); err != nil {
+			return err
+		}
+
+		d.DefaultACLs = append(d.DefaultACLs, def)
+		return nil
+	}
+
+	kind, ok := psqlRelationTypes[cell("Type")]
+	if !ok {
+		return fmt.Errorf("unknown relation type %+q", cell("Type"))
+	}
+
+	obj := Object{Kind: kind, Schema: cell("Schema"), Name: cell("Name")}
+	var err error
+	if obj.ACL, err = psqlACL(cell("Access privileges")); err != nil {
+		return err
+	}
+	if err := obj.Validate(); err != nil {
+		return err
+	}
+	objs := []Object{obj}
+
+	// Column privileges are listed as "column:" followed by its indented
+	// aclitems.
+	var column *Object
+	for _, line := range strings.Split(cell("Column privileges"), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasSuffix(line, ":"):
+			objs = append(objs, Object{Kind: KindColumn, Schema: obj.Schema, Name: obj.Name, Column: strings.TrimSuffix(line, ":"), ACL: []ACL{}})
+			column = &objs[len(objs)-1]
+		case column == nil:
+			return fmt.Errorf("column privilege %+q without a column", line)
+		default:
+			a, err := Parse(line)
+			if err != nil {
+				return err
+			}
+			column.ACL = append(column.ACL, a)
+		}
+	}
+
+	for _, o := range objs[1:] {
+		if err := o.Validate(); err != nil {
+			return err
+		}
+	}
+
+	d.Objects = append(d.Objects, objs...)
+	return nil
+}
+
+// psqlACL parses an Access privileges cell, one aclitem per line.
+func psqlACL(cell string) ([]ACL, error) {
+	switch cell {
+	case "":
+		return nil, nil
+	case "(none)":
+		return []ACL{}, nil
+	}
+
+	var acls []ACL
+	for _, line := range strings.Split(cell, "\n") {
+		a, err := Parse(strings.TrimSpace(line))
+		if err != nil {
+			return nil, err
+		}
+		acls = append(acls, a)
+	}
+
+	return acls, nil
+}
+
+// readAligned reads the rows of an aligned table from lines[start:] up to its
+// footer and returns them with the index of the last line of the table.
+// Lines whose cells end with "+" continue on the next line, and cells ending
+// with "." are wrapped onto a next line starting with ".".
+func readAligned(lines []string, start, columns int, border2 bool) ([]psqlRecord, int) {
+	var rows [][]string
+	end := start
+	for ; end < len(lines); end++ {
+		parts := psqlParts(lines[end], border2)
+		if len(parts) != columns {
+			break
+		}
+		rows = append(rows, parts)
+	}
+
+	var records []psqlRecord
+	var cur *psqlRecord
+	var markers []byte
+	for i, parts := range rows {
+		cells := make([]string, columns)
+		next := make([]byte, columns)
+		for j, part := range parts {
+			// Each cell starts with a space, or "." after a wrap, and ends with
+			// a space, "+" or "." unless it is the last one.
+			if len(part) > 0 {
+				part = part[1:]
+			}
+			switch {
+			case strings.HasSuffix(part, "+"):
+				next[j] = '+'
+				part = part[:len(part)-1]
+			case strings.HasSuffix(part, ".") && i+1 < len(rows) && strings.HasPrefix(rows[i+1][j], "."):
+				next[j] = '.'
+				part = part[:len(part)-1]
+			}
+			cells[j] = strings.TrimSpace(part)
+		}
+
+		if cur == nil || !hasMarker(markers) {
+			records = append(records, psqlRecord{line: start + i + 1, cells: cells})
+			cur = &records[len(records)-1]
+		} else {
+			for j, m := range markers {
+				switch m {
+				case '+':
+					cur.cells[j] += "\n" + cells[j]
+				case '.':
+					cur.cells[j] += cells[j]
+				}
+			}
+		}
+		markers = next
+	}
+
+	return records, end - 1
+}
+
+// readUnaligned reads the rows of an unaligned table from lines[start:] up to
+// its footer and returns them with the index of the last line of the table.
+// Cells with several lines are written with literal newlines, so a row ends
+// once it has a cell for every column and the next line starts another row.
+func readUnaligned(lines []string, start, columns int) ([]psqlRecord, int) {
+	var records []psqlRecord
+	var text string
+	first := 0
+	flush := func() {
+		if text != "" {
+			records = append(records, psqlRecord{line: first + 1, cells: strings.Split(text, "|")})
+		}
+	}
+
+	end := start
+	for ; end < len(lines); end++ {
+		line := lines[end]
+		complete := strings.Count(text, "|") >= columns-1
+		if text == "" || complete {
+			if line == "" || isPsqlFooter(line) || text == "" && !strings.Contains(line, "|") {
+				break
+			}
+		}
+
+		if text == "" || complete && strings.Contains(line, "|") {
+			flush()
+			text, first = line, end
+			continue
+		}
+		text += "\n" + line
+	}
+
+	flush()
+	return records, end - 1
+}
+
+// psqlCells splits a header or row of an aligned table into trimmed cells.
+func psqlCells(line string, border2 bool) []string {
+	parts := psqlParts(line, border2)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	return parts
+}
+
+// psqlParts splits a line of an aligned table at its column separators.
+// With border 2 the outer frame is dropped first.
+func psqlParts(line string, border2 bool) []string {
+	if border2 {
+		if len(line) < 2 || line[0] != '|' || line[len(line)-1] != '|' {
+			return nil
+		}
+		line = line[1 : len(line)-1]
+	}
+
+	return strings.Split(line, "|")
+}
+
+// isPsqlRule reports whether line is the rule psql draws below the header
+// of an aligned table, e.g. "-------+------".
+func isPsqlRule(line string) bool {
+	return strings.Contains(line, "-") && strings.Trim(line, "-+") == ""
+}
+
+// isPsqlFooter reports whether line is a row count such as "(2 rows)".
+func isPsqlFooter(line string) bool {
+	return strings.HasPrefix(line, "(") && (strings.HasSuffix(line, " row)") || strings.HasSuffix(line, " rows)"))
+}
+
+// isPrivilegeTable reports whether header is that of a \dp or \ddp table.
+func isPrivilegeTable(header []string) bool {
+	if !hasColumn(header, "Access privileges") || !hasColumn(header, "Schema") || !hasColumn(header, "Type") {
+		return false
+	}
+
+	return hasColumn(header, "Name") || hasColumn(header, "Owner")
+}
+
+func hasColumn(header []string, name string) bool {
+	for _, h := range header {
+		if strings.TrimSpace(h) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasMarker(markers []byte) bool {
+	for _, m := range markers {
+		if m != 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,170 @@
+package acl_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+func TestReadAccessPrivileges(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		objects  []string
+		defaults []string
+		fail     bool
+	}{
+		{
+			name: "aligned",
+			input: `mydb=> \dp mytable
+                                  Access privileges
+ Schema |  Name   | Type  |   Access privileges    |   Column privileges   | Policies
+--------+---------+-------+------------------------+-----------------------+----------
+ public | mytable | table | miriam=arwdDxt/miriam +| col1:                +|
+        |         |       | =r/miriam             +|   miriam_rw=rw/miriam |
+        |         |       | admin=arw/miriam       |                       |
+ public | plain   | view  |                        |                       |
+ public | ids     | sequence | (none)              |                       |
+(3 rows)
+`,
+			objects: []string{
+				`table:"public"."mytable" [miriam=arwdDxt/miriam =r/miriam admin=arw/miriam]`,
+				`column:"public"."mytable"."col1" [miriam_rw=rw/miriam]`,
+				`table:"public"."plain" nil`,
+				`sequence:"public"."ids" []`,
+			},
+		},
+		{
+			name: "default privileges",
+			input: `         Default access privileges
+ Owner  | Schema | Type  | Access privileges
+--------+--------+-------+-------------------
+ app    | app    | table | ro=r/app         +
+        |        |       | rw=arwd/app
+ app    |        | function | =X/app        +
+        |        |          | app=X/app
+(2 rows)
+`,
+			defaults: []string{
+				`default:table:role=app:schema=app [ro=r/app rw=arwd/app]`,
+				`default:function:role=app [=X/app app=X/app]`,
+			},
+		},
+		{
+			name: "unaligned",
+			input: `Access privileges
+Schema|Name|Type|Access privileges|Column privileges|Policies
+public|mytable|table|miriam=arwdDxt/miriam
+=r/miriam|col1:
+  miriam_rw=rw/miriam
+col2:
+  ro=r/miriam|
+public|other|table|||
+(2 rows)
+`,
+			objects: []string{
+				`table:"public"."mytable" [miriam=arwdDxt/miriam =r/miriam]`,
+				`column:"public"."mytable"."col1" [miriam_rw=rw/miriam]`,
+				`column:"public"."mytable"."col2" [ro=r/miriam]`,
+				`table:"public"."other" nil`,
+			},
+		},
+		{
+			name: "wrapped",
+			input: ` Schema | Name | Type  | Access privileges
+--------+------+-------+-------------------
+ app    | t    | table | app=arwdDxt/app  +
+        |      |       | reporting_read_o.
+        |      |       |.nly=r/app
+(1 row)
+`,
+			objects: []string{
+				`table:"app"."t" [app=arwdDxt/app reporting_read_only=r/app]`,
+			},
+		},
+		{
+			name: "border 2 and other tables",
+			input: `                 List of databases
+ Name | Owner | Access privileges
+------+-------+-------------------
+ app  | app   | =Tc/app
+(1 row)
+
++--------+------+-------+-------------------+
+| Schema | Name | Type  | Access privileges |
++--------+------+-------+-------------------+
+| app    | t    | table | app=arwdDxt/app  +|
+|        |      |       | ro=r/app          |
++--------+------+-------+-------------------+
+(1 row)
+`,
+			objects: []string{
+				`table:"app"."t" [app=arwdDxt/app ro=r/app]`,
+			},
+		},
+		{
+			name: "invalid privilege",
+			input: ` Schema | Name | Type     | Access privileges
+--------+------+----------+-------------------
+ app    | s    | sequence | ro=a/app
+ app    | t    | table    | ro=a/app
+`,
+			objects: []string{
+				`table:"app"."t" [ro=a/app]`,
+			},
+			fail: true,
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			d, err := acl.ReadAccessPrivileges(strings.NewReader(test.input))
+			switch {
+			case err != nil && !test.fail:
+				t.Fatalf("unable to read psql output: %v", err)
+			case err == nil && test.fail:
+				t.Fatalf("expected failure")
+			case err != nil:
+				var se *acl.ScriptError
+				if !errors.As(err, &se) || se.Line != 3 {
+					t.Fatalf("bad: expected an error on line 3, got %v", err)
+				}
+			}
+
+			var objects, defaults []string
+			for _, o := range d.Objects {
+				objects = append(objects, o.Key()+" "+psqlACLString(o.ACL))
+			}
+			for _, def := range d.DefaultACLs {
+				defaults = append(defaults, def.Key()+" "+psqlACLString(def.ACL))
+			}
+
+			if !reflect.DeepEqual(objects, test.objects) {
+				t.Fatalf("bad: expected %#v to equal %#v", objects, test.objects)
+			}
+			if !reflect.DeepEqual(defaults, test.defaults) {
+				t.Fatalf("bad: expected %#v to equal %#v", defaults, test.defaults)
+			}
+		})
+	}
+}
+
+func psqlACLString(acls []acl.ACL) string {
+	if acls == nil {
+		return "nil"
+	}
+
+	s := make([]string, 0, len(acls))
+	for _, a := range acls {
+		s = append(s, a.String())
+	}
+
+	return "[" + strings.Join(s, " ") + "]"
+}
@@ -0,0 +1,78 @@
+package acl
+
+import "fmt"
+
+// RelKind is the pg_class.relkind of a relation.  Every relkind listed below
+// stores its privileges in pg_class.relacl.
+type RelKind byte
+
+const (
+	RelKindTable            RelKind = 'r'
+	RelKindView             RelKind = 'v'
+	RelKindMaterializedView RelKind = 'm'
+	RelKindForeignTable     RelKind = 'f'
+	RelKindPartitionedTable RelKind = 'p'
+	RelKindSequence         RelKind = 'S'
+)
+
+// ParseRelKind returns the RelKind for a pg_class.relkind value.
+func ParseRelKind(s string) (RelKind, error) {
+	if len(s) != 1 {
+		return 0, fmt.Errorf("invalid relkind %+q", s)
+	}
+
+	k := RelKind(s[0])
+	if _, err := k.ObjectKind(); err != nil {
+		return 0, err
+	}
+
+	return k, nil
+}
+
+// String returns the relkind as it appears in pg_class.
+func (k RelKind) String() string {
+	return string(rune(k))
+}
+
+// ObjectKind returns the kind of object whose privileges apply to relations of
+// this relkind.  Indexes, composite types and TOAST tables have no privileges
+// of their own and return an error.
+func (k RelKind) ObjectKind() (ObjectKind, error) {
+	switch k {
+	case RelKindTable, RelKindView, RelKindMaterializedView, RelKindForeignTable, RelKindPartitionedTable:
+		return KindTable, nil
+	case RelKindSequence:
+		return KindSequence, nil
+	default:
+		return "", fmt.Errorf("relkind %+q does not carry privileges", k.String())
+	}
+}
+
+// Validate checks acl against the validator for the relkind's object kind and
+// returns the ACL as PostgreSQL would store it.
+//
+// PostgreSQL accepts GRANT ... ON TABLE for a sequence and silently drops the
+// privileges sequences do not support.  Validate does the same for sequences:
+// table-only privileges are removed from the returned ACL and a warning is
+// returned for them instead of an error.
+func (k RelKind) Validate(acl ACL) (ACL, []string, error) {
+	kind, err := k.ObjectKind()
+	if err != nil {
+		return ACL{}, nil, err
+	}
+
+	var warnings []string
+	if kind == KindSequence {
+		if extra := (acl.Privileges | acl.GrantOptions) &^ validSequencePrivs; extra != NoPrivs {
+			warnings = append(warnings, fmt.Sprintf("sequence only supports USAGE, SELECT, and UPDATE privileges, ignoring %s granted to %s", extra, quoteRole(acl.Role)))
+			acl.Privileges &= validSequencePrivs
+			acl.GrantOptions &= validSequencePrivs
+		}
+	}
+
+	if err := kind.Validate(acl); err != nil {
+		return ACL{}, nil, err
+	}
+
+	return acl, warnings, nil
+}
@@ -0,0 +1,143 @@
+package acl_test
+
+import (
+	"reflect"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+func TestRelKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		relkind  string
+		in       string
+		kind     acl.ObjectKind
+		out      string
+		warnings []string
+		fail     bool
+	}{
+		{
+			name:    "table",
+			relkind: "r",
+			in:      "foo=arwdDxt/bar",
+			kind:    acl.KindTable,
+			out:     "foo=arwdDxt/bar",
+		},
+		{
+			name:    "view",
+			relkind: "v",
+			in:      "foo=r/bar",
+			kind:    acl.KindTable,
+			out:     "foo=r/bar",
+		},
+		{
+			name:    "materialized view",
+			relkind: "m",
+			in:      "foo=r/bar",
+			kind:    acl.KindTable,
+			out:     "foo=r/bar",
+		},
+		{
+			name:    "foreign table",
+			relkind: "f",
+			in:      "foo=arwd/bar",
+			kind:    acl.KindTable,
+			out:     "foo=arwd/bar",
+		},
+		{
+			name:    "partitioned table",
+			relkind: "p",
+			in:      "foo=a*r/bar",
+			kind:    acl.KindTable,
+			out:     "foo=a*r/bar",
+		},
+		{
+			name:    "sequence",
+			relkind: "S",
+			in:      "foo=rwU*/bar",
+			kind:    acl.KindSequence,
+			out:     "foo=rwU*/bar",
+		},
+		{
+			name:    "table privileges on sequence",
+			relkind: "S",
+			in:      "foo=a*rwdU/bar",
+			kind:    acl.KindSequence,
+			out:     "foo=rwU/bar",
+			warnings: []string{
+				`sequence only supports USAGE, SELECT, and UPDATE privileges, ignoring DELETE, INSERT granted to "foo"`,
+			},
+		},
+		{
+			name:    "usage on table",
+			relkind: "r",
+			in:      "foo=U/bar",
+			kind:    acl.KindTable,
+			fail:    true,
+		},
+		{
+			name:    "index",
+			relkind: "i",
+			in:      "foo=r/bar",
+			fail:    true,
+		},
+		{
+			name:    "invalid relkind",
+			relkind: "rv",
+			in:      "foo=r/bar",
+			fail:    true,
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			aclItem, err := acl.Parse(test.in)
+			if err != nil {
+				t.Fatalf("unable to parse ACLItem %+q: %v", test.in, err)
+			}
+
+			relkind, err := acl.ParseRelKind(test.relkind)
+			if err != nil {
+				if !test.fail {
+					t.Fatalf("unable to parse relkind %+q: %v", test.relkind, err)
+				}
+				return
+			}
+
+			kind, err := relkind.ObjectKind()
+			if err != nil {
+				t.Fatalf("unable to classify relkind %+q: %v", test.relkind, err)
+			}
+
+			if kind != test.kind {
+				t.Fatalf("bad: expected %v to equal %v", test.kind, kind)
+			}
+
+			got, warnings, err := relkind.Validate(aclItem)
+			if err != nil && !test.fail {
+				t.Fatalf("unable to validate %+q: %v", test.in, err)
+			}
+
+			if err == nil && test.fail {
+				t.Fatalf("expected failure")
+			}
+
+			if test.fail {
+				return
+			}
+
+			if out := got.String(); out != test.out {
+				t.Fatalf("want %+q got %+q", test.out, out)
+			}
+
+			if !reflect.DeepEqual(test.warnings, warnings) {
+				t.Fatalf("bad: expected %v to equal %v", test.warnings, warnings)
+			}
+		})
+	}
+}
@@ -1,12 +1,5 @@
 package acl
 
-import (
-	"bytes"
-	"fmt"
-
-	"github.com/lib/pq"
-)
-
 // Schema models the privileges of a schema aclitem
 type Schema struct {
 	ACL
@@ -14,8 +7,8 @@ type Schema struct {
 
 // NewSchema parses an ACL object and returns a Schema object.
 func NewSchema(acl ACL) (Schema, error) {
-	if !validRights(acl, validSchemaPrivs) {
-		return Schema{}, fmt.Errorf("invalid flags set for schema (%+q), only %+q allowed", permString(acl.Privileges, acl.GrantOptions), validSchemaPrivs)
+	if err := validRights(acl, validSchemaPrivs, "schema"); err != nil {
+		return Schema{}, err
 	}
 
 	return Schema{ACL: acl}, nil
@@ -49,61 +42,20 @@ func (s Schema) Merge(x Schema) Schema {
 // Grants returns a list of SQL queries that constitute the privileges specified
 // in the receiver for the target schema.
 func (s Schema) Grants(target string) []string {
-	const maxQueries = 2
-	queries := make([]string, 0, maxQueries)
-
-	if s.GetPrivilege(Create) {
-		b := bytes.NewBufferString("GRANT CREATE ON SCHEMA ")
-		fmt.Fprint(b, pq.QuoteIdentifier(target), " TO ", quoteRole(s.Role))
-
-		if s.GetGrantOption(Create) {
-			fmt.Fprint(b, " WITH GRANT OPTION")
-		}
-
-		queries = append(queries, b.String())
-	}
-
-	if s.GetPrivilege(Usage) {
-		b := bytes.NewBufferString("GRANT USAGE ON SCHEMA ")
-		fmt.Fprint(b, pq.QuoteIdentifier(target), " TO ", quoteRole(s.Role))
-
-		if s.GetGrantOption(Usage) {
-			fmt.Fprint(b, " WITH GRANT OPTION")
-		}
-
-		queries = append(queries, b.String())
-	}
-
-	return queries
+	return s.object(target).Grants().SQL()
 }
 
 // Revokes returns a list of SQL queries that remove the privileges specified
 // in the receiver from the target schema.
 func (s Schema) Revokes(target string) []string {
-	const maxQueries = 2
-	queries := make([]string, 0, maxQueries)
-
-	if s.GetPrivilege(Create) {
-		b := bytes.NewBufferString("REVOKE")
-		if s.GetGrantOption(Create) {
-			fmt.Fprint(b, " GRANT OPTION FOR")
-		}
-
-		fmt.Fprint(b, " CREATE ON SCHEMA ")
-		fmt.Fprint(b, pq.QuoteIdentifier(target), " FROM ", quoteRole(s.Role))
-		queries = append(queries, b.String())
-	}
-
-	if s.GetPrivilege(Usage) {
-		b := bytes.NewBufferString("REVOKE")
-		if s.GetGrantOption(Usage) {
-			fmt.Fprint(b, " GRANT OPTION FOR")
-		}
+	return s.object(target).Revokes().SQL()
+}
 
-		fmt.Fprint(b, " USAGE ON SCHEMA ")
-		fmt.Fprint(b, pq.QuoteIdentifier(target), " FROM ", quoteRole(s.Role))
-		queries = append(queries, b.String())
+// object returns the receiver as the sole ACL of the target schema.
+func (s Schema) object(target string) Object {
+	return Object{
+		Kind: KindSchema,
+		Name: target,
+		ACL:  []ACL{s.ACL},
 	}
-
-	return queries
 }
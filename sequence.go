@@ -1,7 +1,5 @@
 package acl
 
-import "fmt"
-
 // Sequence models the privileges of a sequence aclitem
 type Sequence struct {
 	ACL
@@ -10,8 +8,8 @@ type Sequence struct {
 // NewSequence parses a PostgreSQL ACL string for a sequence and returns a Sequence
 // object
 func NewSequence(acl ACL) (Sequence, error) {
-	if !validRights(acl, validSequencePrivs) {
-		return Sequence{}, fmt.Errorf("invalid flags set for sequence (%+q), only %+q allowed", permString(acl.Privileges, acl.GrantOptions), validSequencePrivs)
+	if err := validRights(acl, validSequencePrivs, "sequence"); err != nil {
+		return Sequence{}, err
 	}
 
 	return Sequence{ACL: acl}, nil
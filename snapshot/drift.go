@@ -0,0 +1,495 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+// Report describes how the privileges in one snapshot drifted from another.
+type Report struct {
+	Objects []ObjectDrift `json:"objects"`
+
+	// Extensions holds the drift of objects with a pg_init_privs entry,
+	// i.e. those created by extensions, apart from the objects users create.
+	Extensions []ObjectDrift `json:"extensions"`
+
+	DefaultACLs     []ObjectDrift     `json:"default_acls"`
+	RoleMemberships []MembershipDrift `json:"role_memberships"`
+}
+
+// The values of ObjectDrift.Change and MembershipDrift.Change.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// ObjectDrift lists the aclitems that differ for a single object, or for a
+// single default ACL entry.
+type ObjectDrift struct {
+	Key    string `json:"key"`
+	Change string `json:"change"`
+
+	OldOwner string `json:"old_owner,omitempty"`
+	NewOwner string `json:"new_owner,omitempty"`
+
+	// Added and Removed hold aclitems whose grantee and grantor pair only
+	// exists in the new or old snapshot, respectively.
+	Added   []acl.ACL   `json:"added,omitempty"`
+	Removed []acl.ACL   `json:"removed,omitempty"`
+	Changed []ACLChange `json:"changed,omitempty"`
+}
+
+// ACLChange describes an aclitem whose privileges, grant options or grantor
+// changed between snapshots.
+type ACLChange struct {
+	Role string  `json:"role"`
+	Old  acl.ACL `json:"old"`
+	New  acl.ACL `json:"new"`
+
+	PrivilegesGranted   []string `json:"privileges_granted,omitempty"`
+	PrivilegesRevoked   []string `json:"privileges_revoked,omitempty"`
+	GrantOptionsGranted []string `json:"grant_options_granted,omitempty"`
+	GrantOptionsRevoked []string `json:"grant_options_revoked,omitempty"`
+	GrantorChanged      bool     `json:"grantor_changed,omitempty"`
+}
+
+// MembershipDrift is a role membership that was added, removed or changed.
+// Since PostgreSQL 16 a member can hold the same role once per grantor, so
+// memberships are matched by role, member and grantor.  As with aclitems, a
+// member holding the role from exactly one grantor on each side is reported
+// as a grantor change.  For changes, RoleMembership is the new membership and
+// Old the previous one.
+type MembershipDrift struct {
+	Change string `json:"change"`
+	RoleMembership
+
+	Old            *RoleMembership `json:"old,omitempty"`
+	GrantorChanged bool            `json:"grantor_changed,omitempty"`
+}
+
+// Diff returns the drift from before to after.  Both snapshots are
+// normalized.
+func Diff(before, after *Snapshot) *Report {
+	before.Normalize()
+	after.Normalize()
+
+	r := &Report{
+		Objects:         []ObjectDrift{},
+		Extensions:      []ObjectDrift{},
+		DefaultACLs:     []ObjectDrift{},
+		RoleMemberships: []MembershipDrift{},
+	}
+
+	oldObjs, newObjs := before.Index(), after.Index()
+	for _, key := range unionKeys(oldObjs, newObjs) {
+		o, inOld := oldObjs[key]
+		n, inNew := newObjs[key]
+
+		d := ObjectDrift{Key: key}
+		switch {
+		case !inOld:
+			d.Change = Added
+			d.NewOwner = n.Owner
+		case !inNew:
+			d.Change = Removed
+			d.OldOwner = o.Owner
+		default:
+			d.Change = Changed
+			if o.Owner != n.Owner {
+				d.OldOwner, d.NewOwner = o.Owner, n.Owner
+			}
+		}
+
+		d.Added, d.Removed, d.Changed = diffACL(o.ACL, n.ACL)
+		if d.Change == Changed && d.OldOwner == "" && d.NewOwner == "" && d.empty() {
+			continue
+		}
+
+		if o.InitialACL != nil || n.InitialACL != nil {
+			r.Extensions = append(r.Extensions, d)
+		} else {
+			r.Objects = append(r.Objects, d)
+		}
+	}
+
+	oldDefs, newDefs := indexDefaults(before.DefaultACLs), indexDefaults(after.DefaultACLs)
+	for _, key := range unionKeys(oldDefs, newDefs) {
+		o, inOld := oldDefs[key]
+		n, inNew := newDefs[key]
+
+		d := ObjectDrift{Key: key, Change: Changed}
+		switch {
+		case !inOld:
+			d.Change = Added
+		case !inNew:
+			d.Change = Removed
+		}
+
+		d.Added, d.Removed, d.Changed = diffACL(o.ACL, n.ACL)
+		if d.Change != Changed || !d.empty() {
+			r.DefaultACLs = append(r.DefaultACLs, d)
+		}
+	}
+
+	r.RoleMemberships = diffMemberships(before.RoleMemberships, after.RoleMemberships)
+
+	return r
+}
+
+// Empty returns true if the report contains no drift.
+func (r *Report) Empty() bool {
+	return len(r.Objects) == 0 && len(r.Extensions) == 0 && len(r.DefaultACLs) == 0 && len(r.RoleMemberships) == 0
+}
+
+// WriteJSON writes the report to w as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+// WriteText writes the report to w in a line-oriented format meant for
+// terminals and log files.
+func (r *Report) WriteText(w io.Writer) error {
+	b := new(strings.Builder)
+	if r.Empty() {
+		fmt.Fprintln(b, "no drift")
+	}
+
+	writeText := func(title string, drifts []ObjectDrift) {
+		if len(drifts) == 0 {
+			return
+		}
+
+		fmt.Fprintln(b, title+":")
+		for _, d := range drifts {
+			fmt.Fprintf(b, "  %s (%s)\n", d.Key, d.Change)
+			if d.OldOwner != "" && d.NewOwner != "" {
+				fmt.Fprintf(b, "    owner: %s -> %s\n", d.OldOwner, d.NewOwner)
+			}
+			for _, a := range d.Added {
+				fmt.Fprintf(b, "    + %s\n", a)
+			}
+			for _, a := range d.Removed {
+				fmt.Fprintf(b, "    - %s\n", a)
+			}
+			for _, c := range d.Changed {
+				fmt.Fprintf(b, "    ~ %s -> %s: %s\n", c.Old, c.New, c.summary())
+			}
+		}
+	}
+
+	writeText("objects", r.Objects)
+	writeText("extension objects", r.Extensions)
+	writeText("default ACLs", r.DefaultACLs)
+
+	if len(r.RoleMemberships) > 0 {
+		fmt.Fprintln(b, "role memberships:")
+		for _, m := range r.RoleMemberships {
+			sign := "+"
+			switch m.Change {
+			case Removed:
+				sign = "-"
+			case Changed:
+				sign = "~"
+			}
+			fmt.Fprintf(b, "  %s %s\n", sign, m.summary())
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown writes the report to w as Markdown tables, suitable for pull
+// request comments and issue trackers.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	b := new(strings.Builder)
+	fmt.Fprintln(b, "# Privilege drift")
+	if r.Empty() {
+		fmt.Fprintln(b)
+		fmt.Fprintln(b, "No drift.")
+	}
+
+	writeTable := func(title string, drifts []ObjectDrift) {
+		if len(drifts) == 0 {
+			return
+		}
+
+		fmt.Fprintf(b, "\n## %s\n\n", title)
+		fmt.Fprintln(b, "| Object | Change | Old | New | Details |")
+		fmt.Fprintln(b, "| --- | --- | --- | --- | --- |")
+		for _, d := range drifts {
+			key := markdownCell(d.Key)
+			if d.OldOwner != "" && d.NewOwner != "" {
+				fmt.Fprintf(b, "| %s | owner | %s | %s | |\n", key, markdownCell(d.OldOwner), markdownCell(d.NewOwner))
+			}
+			for _, a := range d.Added {
+				fmt.Fprintf(b, "| %s | added | | `%s` | |\n", key, markdownCell(a.String()))
+			}
+			for _, a := range d.Removed {
+				fmt.Fprintf(b, "| %s | removed | `%s` | | |\n", key, markdownCell(a.String()))
+			}
+			for _, c := range d.Changed {
+				fmt.Fprintf(b, "| %s | changed | `%s` | `%s` | %s |\n", key, markdownCell(c.Old.String()), markdownCell(c.New.String()), markdownCell(c.summary()))
+			}
+			if d.empty() && d.Change != Changed {
+				fmt.Fprintf(b, "| %s | %s | | | |\n", key, d.Change)
+			}
+		}
+	}
+
+	writeTable("Objects", r.Objects)
+	writeTable("Extension objects", r.Extensions)
+	writeTable("Default ACLs", r.DefaultACLs)
+
+	if len(r.RoleMemberships) > 0 {
+		fmt.Fprintf(b, "\n## Role memberships\n\n")
+		fmt.Fprintln(b, "| Change | Membership |")
+		fmt.Fprintln(b, "| --- | --- |")
+		for _, m := range r.RoleMemberships {
+			fmt.Fprintf(b, "| %s | %s |\n", m.Change, markdownCell(m.summary()))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (d ObjectDrift) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// summary describes the change in one line, separating privilege, grant
+// option and grantor changes.
+func (c ACLChange) summary() string {
+	var parts []string
+	if len(c.PrivilegesGranted) > 0 {
+		parts = append(parts, "privileges granted: "+strings.Join(c.PrivilegesGranted, ", "))
+	}
+	if len(c.PrivilegesRevoked) > 0 {
+		parts = append(parts, "privileges revoked: "+strings.Join(c.PrivilegesRevoked, ", "))
+	}
+	if len(c.GrantOptionsGranted) > 0 {
+		parts = append(parts, "grant options granted: "+strings.Join(c.GrantOptionsGranted, ", "))
+	}
+	if len(c.GrantOptionsRevoked) > 0 {
+		parts = append(parts, "grant options revoked: "+strings.Join(c.GrantOptionsRevoked, ", "))
+	}
+	if c.GrantorChanged {
+		parts = append(parts, fmt.Sprintf("grantor: %s -> %s", roleName(c.Old.GrantedBy), roleName(c.New.GrantedBy)))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+func (m MembershipDrift) summary() string {
+	if m.Change != Changed {
+		return m.RoleMembership.summary()
+	}
+
+	var parts []string
+	if m.GrantorChanged {
+		parts = append(parts, fmt.Sprintf("grantor: %s -> %s", m.Old.Grantor, m.Grantor))
+	}
+	switch {
+	case m.AdminOption && !m.Old.AdminOption:
+		parts = append(parts, "admin option granted")
+	case !m.AdminOption && m.Old.AdminOption:
+		parts = append(parts, "admin option revoked")
+	}
+
+	return fmt.Sprintf("%s in %s: %s", m.Member, m.Role, strings.Join(parts, "; "))
+}
+
+func (rm RoleMembership) summary() string {
+	s := fmt.Sprintf("%s in %s", rm.Member, rm.Role)
+	if rm.Grantor != "" {
+		s += " granted by " + rm.Grantor
+	}
+	if rm.AdminOption {
+		s += " with admin option"
+	}
+
+	return s
+}
+
+// diffMemberships compares two membership lists the way diffACL compares
+// ACL lists, with the role and member in place of the grantee.
+func diffMemberships(before, after []RoleMembership) []MembershipDrift {
+	drifts := []MembershipDrift{}
+	oldByPair, newByPair := groupMemberships(before), groupMemberships(after)
+	for _, pair := range unionKeys(oldByPair, newByPair) {
+		o, n := oldByPair[pair], newByPair[pair]
+
+		if len(o) == 1 && len(n) == 1 && o[0].Grantor != n[0].Grantor {
+			drifts = append(drifts, newMembershipChange(o[0], n[0]))
+			continue
+		}
+
+		oldByGrantor, newByGrantor := indexGrantors(o), indexGrantors(n)
+		for _, grantor := range unionKeys(oldByGrantor, newByGrantor) {
+			om, inOld := oldByGrantor[grantor]
+			nm, inNew := newByGrantor[grantor]
+
+			switch {
+			case !inOld:
+				drifts = append(drifts, MembershipDrift{Change: Added, RoleMembership: nm})
+			case !inNew:
+				drifts = append(drifts, MembershipDrift{Change: Removed, RoleMembership: om})
+			case om != nm:
+				drifts = append(drifts, newMembershipChange(om, nm))
+			}
+		}
+	}
+
+	return drifts
+}
+
+func newMembershipChange(before, after RoleMembership) MembershipDrift {
+	return MembershipDrift{
+		Change:         Changed,
+		RoleMembership: after,
+		Old:            &before,
+		GrantorChanged: before.Grantor != after.Grantor,
+	}
+}
+
+// diffACL compares two ACL lists.  Entries are matched by grantee and
+// grantor.  If a grantee holds exactly one entry on each side but the
+// grantors differ, the entries are reported as a single grantor change.
+func diffACL(before, after []acl.ACL) (added, removed []acl.ACL, changed []ACLChange) {
+	oldByRole, newByRole := groupByRole(before), groupByRole(after)
+	for _, role := range unionKeys(oldByRole, newByRole) {
+		o, n := oldByRole[role], newByRole[role]
+
+		if len(o) == 1 && len(n) == 1 && o[0].GrantedBy != n[0].GrantedBy {
+			changed = append(changed, newACLChange(o[0], n[0]))
+			continue
+		}
+
+		oldByGrantor, newByGrantor := groupByGrantor(o), groupByGrantor(n)
+		for _, grantor := range unionKeys(oldByGrantor, newByGrantor) {
+			oa, inOld := oldByGrantor[grantor]
+			na, inNew := newByGrantor[grantor]
+
+			switch {
+			case !inOld:
+				added = append(added, na)
+			case !inNew:
+				removed = append(removed, oa)
+			case oa != na:
+				changed = append(changed, newACLChange(oa, na))
+			}
+		}
+	}
+
+	return added, removed, changed
+}
+
+func newACLChange(before, after acl.ACL) ACLChange {
+	return ACLChange{
+		Role:                before.Role,
+		Old:                 before,
+		New:                 after,
+		PrivilegesGranted:   (after.Privileges &^ before.Privileges).Names(),
+		PrivilegesRevoked:   (before.Privileges &^ after.Privileges).Names(),
+		GrantOptionsGranted: (after.GrantOptions &^ before.GrantOptions).Names(),
+		GrantOptionsRevoked: (before.GrantOptions &^ after.GrantOptions).Names(),
+		GrantorChanged:      before.GrantedBy != after.GrantedBy,
+	}
+}
+
+func groupByRole(acls []acl.ACL) map[string][]acl.ACL {
+	m := make(map[string][]acl.ACL)
+	for _, a := range acls {
+		m[a.Role] = append(m[a.Role], a)
+	}
+
+	return m
+}
+
+// groupByGrantor merges duplicate entries for the same grantor, which
+// PostgreSQL never stores but hand-edited snapshots may contain.
+func groupByGrantor(acls []acl.ACL) map[string]acl.ACL {
+	m := make(map[string]acl.ACL)
+	for _, a := range acls {
+		prev := m[a.GrantedBy]
+		a.Privileges |= prev.Privileges
+		a.GrantOptions |= prev.GrantOptions
+		m[a.GrantedBy] = a
+	}
+
+	return m
+}
+
+func indexDefaults(defs []DefaultACL) map[string]DefaultACL {
+	m := make(map[string]DefaultACL, len(defs))
+	for _, d := range defs {
+		m[d.Key()] = d
+	}
+
+	return m
+}
+
+func groupMemberships(members []RoleMembership) map[string][]RoleMembership {
+	m := make(map[string][]RoleMembership)
+	for _, rm := range members {
+		key := rm.Role + "\x00" + rm.Member
+		m[key] = append(m[key], rm)
+	}
+
+	return m
+}
+
+// indexGrantors keys memberships of one role and member by grantor.
+// Duplicates, which PostgreSQL never stores, keep the admin option of either.
+func indexGrantors(members []RoleMembership) map[string]RoleMembership {
+	m := make(map[string]RoleMembership, len(members))
+	for _, rm := range members {
+		rm.AdminOption = rm.AdminOption || m[rm.Grantor].AdminOption
+		m[rm.Grantor] = rm
+	}
+
+	return m
+}
+
+// unionKeys returns the sorted union of the keys of a and b.
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// roleName returns role, or PUBLIC for the empty role.
+func roleName(role string) string {
+	if role == "" {
+		return "PUBLIC"
+	}
+
+	return role
+}
+
+// markdownCell escapes the characters that would break a Markdown table.
+// Pipes are escaped inside code spans too, as tables are split before code
+// spans are parsed.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
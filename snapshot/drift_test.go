@@ -0,0 +1,262 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+	"github.com/sean-/postgresql-acl/snapshot"
+)
+
+func mustParse(t *testing.T, aclStrs ...string) []acl.ACL {
+	t.Helper()
+
+	acls := make([]acl.ACL, 0, len(aclStrs))
+	for _, aclStr := range aclStrs {
+		a, err := acl.Parse(aclStr)
+		if err != nil {
+			t.Fatalf("unable to parse ACLItem %+q: %v", aclStr, err)
+		}
+		acls = append(acls, a)
+	}
+
+	return acls
+}
+
+func driftSnapshots(t *testing.T) (*snapshot.Snapshot, *snapshot.Snapshot) {
+	before := &snapshot.Snapshot{
+		Version: snapshot.Version,
+		Objects: []acl.Object{
+			{Kind: acl.KindTable, Schema: "public", Name: "accounts", Owner: "app", ACL: mustParse(t, "app=arwdDxt/app", "ro=r/app", "etl=arw/app")},
+			{Kind: acl.KindTable, Schema: "public", Name: "dropped", Owner: "app", ACL: mustParse(t, "app=arwdDxt/app")},
+			{Kind: acl.KindSchema, Name: "public", Owner: "postgres", ACL: mustParse(t, "postgres=UC/postgres", "=U/postgres")},
+		},
+		DefaultACLs: []snapshot.DefaultACL{
+			{Role: "app", Schema: "public", Kind: acl.KindTable, ACL: mustParse(t, "ro=r/app")},
+		},
+		RoleMemberships: []snapshot.RoleMembership{
+			{Role: "readers", Member: "ro", Grantor: "postgres"},
+		},
+	}
+
+	after := &snapshot.Snapshot{
+		Version: snapshot.Version,
+		Objects: []acl.Object{
+			{Kind: acl.KindTable, Schema: "public", Name: "accounts", Owner: "app", ACL: mustParse(t, "app=arwdDxt/app", "ro=r*w/app", "etl=arw/postgres", "intern=r/app")},
+			{Kind: acl.KindTable, Schema: "public", Name: "created", Owner: "bob", ACL: mustParse(t, "bob=arwdDxt/bob")},
+			{Kind: acl.KindSchema, Name: "public", Owner: "app", ACL: mustParse(t, "postgres=UC/postgres", "=U/postgres")},
+		},
+		DefaultACLs: []snapshot.DefaultACL{
+			{Role: "app", Schema: "public", Kind: acl.KindTable, ACL: mustParse(t, "ro=r/app", "intern=r/app")},
+		},
+		RoleMemberships: []snapshot.RoleMembership{
+			{Role: "readers", Member: "ro", Grantor: "postgres"},
+			{Role: "writers", Member: "intern", Grantor: "postgres", AdminOption: true},
+		},
+	}
+
+	return before, after
+}
+
+func TestDiffText(t *testing.T) {
+	before, after := driftSnapshots(t)
+
+	b := new(bytes.Buffer)
+	if err := snapshot.Diff(before, after).WriteText(b); err != nil {
+		t.Fatalf("unable to write report: %v", err)
+	}
+
+	const want = `objects:
+  schema:"public" (changed)
+    owner: postgres -> app
+  table:"public"."accounts" (changed)
+    + intern=r/app
+    ~ etl=arw/app -> etl=arw/postgres: grantor: app -> postgres
+    ~ ro=r/app -> ro=r*w/app: privileges granted: UPDATE; grant options granted: SELECT
+  table:"public"."created" (added)
+    + bob=arwdDxt/bob
+  table:"public"."dropped" (removed)
+    - app=arwdDxt/app
+default ACLs:
+  default:table:role=app:schema=public (changed)
+    + intern=r/app
+role memberships:
+  + intern in writers granted by postgres with admin option
+`
+	if b.String() != want {
+		t.Fatalf("bad: expected %s to equal %s", want, b.String())
+	}
+}
+
+func TestDiffMarkdown(t *testing.T) {
+	before, after := driftSnapshots(t)
+
+	b := new(bytes.Buffer)
+	if err := snapshot.Diff(before, after).WriteMarkdown(b); err != nil {
+		t.Fatalf("unable to write report: %v", err)
+	}
+
+	const want = "# Privilege drift\n" +
+		"\n## Objects\n\n" +
+		"| Object | Change | Old | New | Details |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| schema:\"public\" | owner | postgres | app | |\n" +
+		"| table:\"public\".\"accounts\" | added | | `intern=r/app` | |\n" +
+		"| table:\"public\".\"accounts\" | changed | `etl=arw/app` | `etl=arw/postgres` | grantor: app -> postgres |\n" +
+		"| table:\"public\".\"accounts\" | changed | `ro=r/app` | `ro=r*w/app` | privileges granted: UPDATE; grant options granted: SELECT |\n" +
+		"| table:\"public\".\"created\" | added | | `bob=arwdDxt/bob` | |\n" +
+		"| table:\"public\".\"dropped\" | removed | `app=arwdDxt/app` | | |\n" +
+		"\n## Default ACLs\n\n" +
+		"| Object | Change | Old | New | Details |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| default:table:role=app:schema=public | added | | `intern=r/app` | |\n" +
+		"\n## Role memberships\n\n" +
+		"| Change | Membership |\n" +
+		"| --- | --- |\n" +
+		"| added | intern in writers granted by postgres with admin option |\n"
+	if b.String() != want {
+		t.Fatalf("bad: expected %s to equal %s", want, b.String())
+	}
+}
+
+func TestDiffMarkdownEscaping(t *testing.T) {
+	table := func(acls ...acl.ACL) *snapshot.Snapshot {
+		return &snapshot.Snapshot{
+			Version: snapshot.Version,
+			Objects: []acl.Object{{Kind: acl.KindTable, Schema: "public", Name: "a|b", Owner: "app", ACL: acls}},
+		}
+	}
+
+	before := table(acl.ACL{Role: "x|y", GrantedBy: "app", Privileges: acl.Select}, acl.ACL{Role: "old|role", GrantedBy: "app", Privileges: acl.Select})
+	after := table(acl.ACL{Role: "x|y", GrantedBy: "app", Privileges: acl.Select | acl.Update}, acl.ACL{Role: "new|role", GrantedBy: "app", Privileges: acl.Select})
+
+	b := new(bytes.Buffer)
+	if err := snapshot.Diff(before, after).WriteMarkdown(b); err != nil {
+		t.Fatalf("unable to write report: %v", err)
+	}
+
+	const want = "# Privilege drift\n" +
+		"\n## Objects\n\n" +
+		"| Object | Change | Old | New | Details |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| table:\"public\".\"a\\|b\" | added | | `new\\|role=r/app` | |\n" +
+		"| table:\"public\".\"a\\|b\" | removed | `old\\|role=r/app` | | |\n" +
+		"| table:\"public\".\"a\\|b\" | changed | `x\\|y=r/app` | `x\\|y=rw/app` | privileges granted: UPDATE |\n"
+	if b.String() != want {
+		t.Fatalf("bad: expected %s to equal %s", want, b.String())
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	before, after := driftSnapshots(t)
+
+	b := new(bytes.Buffer)
+	if err := snapshot.Diff(before, after).WriteJSON(b); err != nil {
+		t.Fatalf("unable to write report: %v", err)
+	}
+
+	var got snapshot.Report
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("unable to decode report: %v", err)
+	}
+
+	if len(got.Objects) != 4 {
+		t.Fatalf("bad: expected 4 objects, got %d", len(got.Objects))
+	}
+
+	accounts := got.Objects[1]
+	if len(accounts.Changed) != 2 {
+		t.Fatalf("bad: expected 2 changes, got %+v", accounts.Changed)
+	}
+
+	ro := accounts.Changed[1]
+	if ro.Role != "ro" || ro.New.String() != "ro=r*w/app" || len(ro.GrantOptionsGranted) != 1 || ro.GrantorChanged {
+		t.Fatalf("bad: unexpected change %+v", ro)
+	}
+}
+
+func TestDiffExtensions(t *testing.T) {
+	fn := acl.Object{Kind: acl.KindFunction, Schema: "public", Name: "pg_stat_statements_reset", Owner: "postgres", ACL: mustParse(t, "postgres=X/postgres")}
+	fn.InitialACL = fn.ACL
+
+	before := &snapshot.Snapshot{Version: snapshot.Version, Objects: []acl.Object{fn}}
+	fn.ACL = mustParse(t, "postgres=X/postgres", "monitor=X/postgres")
+	after := &snapshot.Snapshot{Version: snapshot.Version, Objects: []acl.Object{fn}}
+
+	r := snapshot.Diff(before, after)
+	if len(r.Objects) != 0 || len(r.Extensions) != 1 || r.Empty() {
+		t.Fatalf("bad: expected one extension object, got %+v", r)
+	}
+
+	b := new(bytes.Buffer)
+	if err := r.WriteText(b); err != nil {
+		t.Fatalf("unable to write report: %v", err)
+	}
+
+	const want = `extension objects:
+  function:"public"."pg_stat_statements_reset"() (changed)
+    + monitor=X/postgres
+`
+	if b.String() != want {
+		t.Fatalf("bad: expected %s to equal %s", want, b.String())
+	}
+}
+
+func TestDiffMemberships(t *testing.T) {
+	before := &snapshot.Snapshot{
+		Version: snapshot.Version,
+		RoleMemberships: []snapshot.RoleMembership{
+			{Role: "readers", Member: "ro", Grantor: "postgres"},
+			{Role: "writers", Member: "etl", Grantor: "admin"},
+			{Role: "writers", Member: "rw", Grantor: "admin"},
+		},
+	}
+	after := &snapshot.Snapshot{
+		Version: snapshot.Version,
+		RoleMemberships: []snapshot.RoleMembership{
+			{Role: "readers", Member: "ro", Grantor: "app"},
+			{Role: "writers", Member: "etl", Grantor: "admin", AdminOption: true},
+			{Role: "writers", Member: "rw", Grantor: "admin"},
+			{Role: "writers", Member: "rw", Grantor: "postgres"},
+		},
+	}
+
+	r := snapshot.Diff(before, after)
+	if len(r.RoleMemberships) != 3 || !r.RoleMemberships[0].GrantorChanged {
+		t.Fatalf("bad: expected 3 membership changes, got %+v", r.RoleMemberships)
+	}
+
+	b := new(bytes.Buffer)
+	if err := r.WriteText(b); err != nil {
+		t.Fatalf("unable to write report: %v", err)
+	}
+
+	const want = `role memberships:
+  ~ ro in readers: grantor: postgres -> app
+  ~ etl in writers: admin option granted
+  + rw in writers granted by postgres
+`
+	if b.String() != want {
+		t.Fatalf("bad: expected %s to equal %s", want, b.String())
+	}
+}
+
+func TestDiffNoDrift(t *testing.T) {
+	before, _ := driftSnapshots(t)
+	same, _ := driftSnapshots(t)
+
+	r := snapshot.Diff(before, same)
+	if !r.Empty() {
+		t.Fatalf("bad: expected no drift, got %+v", r)
+	}
+
+	b := new(bytes.Buffer)
+	if err := r.WriteText(b); err != nil {
+		t.Fatalf("unable to write report: %v", err)
+	}
+
+	if b.String() != "no drift\n" {
+		t.Fatalf("bad: expected %q to equal %q", "no drift\n", b.String())
+	}
+}
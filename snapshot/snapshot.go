@@ -0,0 +1,211 @@
+// Package snapshot captures every ACL, default ACL, owner and role membership
+// in a database as a deterministic, versioned JSON document.
+//
+// Two captures of the same privileges produce byte-identical files: objects
+// are sorted by their key, ACL entries by grantee and grantor, and OIDs are
+// dropped for every object that is identified by name.  This makes snapshots
+// suitable for committing to version control and reviewing with diff.
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	acl "github.com/sean-/postgresql-acl"
+	"github.com/sean-/postgresql-acl/catalog"
+)
+
+// Version is the format version written by Write.  Read rejects snapshots with
+// a newer version.
+const Version = 1
+
+// Snapshot is the privilege state of a database at a point in time.
+type Snapshot struct {
+	Version         int              `json:"version"`
+	Objects         []acl.Object     `json:"objects"`
+	DefaultACLs     []DefaultACL     `json:"default_acls"`
+	RoleMemberships []RoleMembership `json:"role_memberships"`
+}
+
+// DefaultACL is the set of privileges applied to objects of Kind created by
+// Role, optionally only within Schema.
+type DefaultACL = acl.DefaultACL
+
+// RoleMembership records that Member is a member of Role.
+type RoleMembership struct {
+	Role        string `json:"role"`
+	Member      string `json:"member"`
+	Grantor     string `json:"grantor,omitempty"`
+	AdminOption bool   `json:"admin_option,omitempty"`
+}
+
+// Capture loads the catalog with catalog.Load and returns its snapshot.
+func Capture(ctx context.Context, q catalog.Querier, schemas ...string) (*Snapshot, error) {
+	c, err := catalog.Load(ctx, q, schemas...)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromCatalog(c), nil
+}
+
+// FromCatalog returns a normalized snapshot of c.
+func FromCatalog(c *catalog.Catalog) *Snapshot {
+	s := &Snapshot{
+		Version: Version,
+		Objects: c.Objects(),
+	}
+
+	s.DefaultACLs = append(s.DefaultACLs, c.DefaultACLs...)
+
+	for _, m := range c.RoleMemberships {
+		s.RoleMemberships = append(s.RoleMemberships, RoleMembership{
+			Role:        m.Role,
+			Member:      m.Member,
+			Grantor:     m.Grantor,
+			AdminOption: m.AdminOption,
+		})
+	}
+
+	s.Normalize()
+	return s
+}
+
+// FromDump returns a normalized snapshot of the privileges in a pg_dump
+// script read with acl.ReadDump, for comparison with a captured database.
+// Dumps carry no role memberships.
+func FromDump(d *acl.Dump) *Snapshot {
+	s := &Snapshot{Version: Version}
+	s.Objects = append(s.Objects, d.Objects...)
+	s.DefaultACLs = append(s.DefaultACLs, d.DefaultACLs...)
+
+	s.Normalize()
+	return s
+}
+
+// Normalize sorts every list in the snapshot and clears the OIDs of objects
+// that are identified by name so equal privilege states compare and serialize
+// equally.
+func (s *Snapshot) Normalize() {
+	if s.Objects == nil {
+		s.Objects = []acl.Object{}
+	}
+	if s.DefaultACLs == nil {
+		s.DefaultACLs = []DefaultACL{}
+	}
+	if s.RoleMemberships == nil {
+		s.RoleMemberships = []RoleMembership{}
+	}
+
+	for i := range s.Objects {
+		o := &s.Objects[i]
+		if o.Kind != acl.KindLargeObject {
+			o.OID = 0
+		}
+		o.ACL = sortACL(o.ACL)
+		if o.InitialACL != nil {
+			o.InitialACL = sortACL(o.InitialACL)
+		}
+	}
+
+	sort.SliceStable(s.Objects, func(i, j int) bool {
+		return s.Objects[i].Key() < s.Objects[j].Key()
+	})
+
+	for i := range s.DefaultACLs {
+		s.DefaultACLs[i].ACL = sortACL(s.DefaultACLs[i].ACL)
+	}
+
+	sort.SliceStable(s.DefaultACLs, func(i, j int) bool {
+		a, b := s.DefaultACLs[i], s.DefaultACLs[j]
+		if a.Role != b.Role {
+			return a.Role < b.Role
+		}
+		if a.Schema != b.Schema {
+			return a.Schema < b.Schema
+		}
+		return a.Kind < b.Kind
+	})
+
+	sort.SliceStable(s.RoleMemberships, func(i, j int) bool {
+		a, b := s.RoleMemberships[i], s.RoleMemberships[j]
+		if a.Role != b.Role {
+			return a.Role < b.Role
+		}
+		if a.Member != b.Member {
+			return a.Member < b.Member
+		}
+		return a.Grantor < b.Grantor
+	})
+}
+
+// Index returns the snapshot's objects keyed by acl.Object.Key.
+func (s *Snapshot) Index() map[string]acl.Object {
+	idx := make(map[string]acl.Object, len(s.Objects))
+	for _, o := range s.Objects {
+		idx[o.Key()] = o
+	}
+
+	return idx
+}
+
+// Write normalizes the snapshot and writes it to w as indented JSON.
+func (s *Snapshot) Write(w io.Writer) error {
+	s.Version = Version
+	s.Normalize()
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(s)
+}
+
+// Read parses a snapshot written by Write and validates every ACL in it
+// against its object kind.
+func Read(r io.Reader) (*Snapshot, error) {
+	s := &Snapshot{}
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, fmt.Errorf("unable to decode snapshot: %w", err)
+	}
+
+	if s.Version < 1 || s.Version > Version {
+		return nil, fmt.Errorf("unsupported snapshot version %d, only versions 1 through %d are supported", s.Version, Version)
+	}
+
+	for _, o := range s.Objects {
+		if err := o.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, d := range s.DefaultACLs {
+		for _, a := range d.ACL {
+			if err := d.Kind.Validate(a); err != nil {
+				return nil, fmt.Errorf("default ACL for %+q: %w", d.Role, err)
+			}
+		}
+	}
+
+	s.Normalize()
+	return s, nil
+}
+
+// sortACL returns a copy of acls ordered by grantee and grantor.  A nil list is
+// returned as an empty list so it serializes as [].
+func sortACL(acls []acl.ACL) []acl.ACL {
+	sorted := make([]acl.ACL, len(acls))
+	copy(sorted, acls)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Role != sorted[j].Role {
+			return sorted[i].Role < sorted[j].Role
+		}
+		return sorted[i].GrantedBy < sorted[j].GrantedBy
+	})
+
+	return sorted
+}
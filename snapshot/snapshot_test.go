@@ -0,0 +1,224 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+	"github.com/sean-/postgresql-acl/internal/fakedb"
+	"github.com/sean-/postgresql-acl/snapshot"
+)
+
+const golden = `{
+  "version": 1,
+  "objects": [
+    {
+      "kind": "large_object",
+      "oid": 16440,
+      "owner": "app",
+      "acl": [
+        "app=rw/app"
+      ]
+    },
+    {
+      "kind": "schema",
+      "name": "public",
+      "owner": "postgres",
+      "acl": [
+        "=U/postgres",
+        "postgres=UC/postgres"
+      ]
+    },
+    {
+      "kind": "table",
+      "schema": "public",
+      "name": "accounts",
+      "owner": "app",
+      "acl": [
+        "app=arwdDxt/app",
+        "ro=r/app"
+      ]
+    }
+  ],
+  "default_acls": [
+    {
+      "role": "app",
+      "schema": "public",
+      "kind": "table",
+      "acl": [
+        "ro=r/app"
+      ]
+    }
+  ],
+  "role_memberships": [
+    {
+      "role": "readers",
+      "member": "ro",
+      "grantor": "postgres"
+    }
+  ]
+}
+`
+
+func snapshotDB() *fakedb.DB {
+	return fakedb.New(
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_namespace n\n",
+			Rows: [][]driver.Value{
+				{int64(2200), "public", "postgres", "{postgres=UC/postgres,=U/postgres}"},
+			},
+		},
+		fakedb.Result{
+			Match: "c.relkind IN",
+			Rows: [][]driver.Value{
+				{int64(16384), "public", "accounts", "r", "app", "{ro=r/app,app=arwdDxt/app}"},
+			},
+		},
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_largeobject_metadata m",
+			Rows: [][]driver.Value{
+				{int64(16440), "app", "{app=rw/app}"},
+			},
+		},
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_default_acl d",
+			Rows: [][]driver.Value{
+				{"app", "public", "r", "{ro=r/app}"},
+			},
+		},
+		fakedb.Result{
+			Match: "FROM pg_catalog.pg_auth_members a",
+			Rows: [][]driver.Value{
+				{"readers", "ro", "postgres", false},
+			},
+		},
+	)
+}
+
+func TestCapture(t *testing.T) {
+	db := snapshotDB().Open()
+	defer db.Close()
+
+	s, err := snapshot.Capture(context.Background(), db)
+	if err != nil {
+		t.Fatalf("unable to capture snapshot: %v", err)
+	}
+
+	b := new(bytes.Buffer)
+	if err := s.Write(b); err != nil {
+		t.Fatalf("unable to write snapshot: %v", err)
+	}
+
+	if b.String() != golden {
+		t.Fatalf("bad: expected %s to equal %s", golden, b.String())
+	}
+
+	got, err := snapshot.Read(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("unable to read snapshot: %v", err)
+	}
+
+	if !reflect.DeepEqual(s, got) {
+		t.Fatalf("bad: expected %+v to equal %+v", s, got)
+	}
+}
+
+func TestFromDump(t *testing.T) {
+	const dump = `
+ALTER SCHEMA public OWNER TO postgres;
+ALTER TABLE public.accounts OWNER TO app;
+ALTER LARGE OBJECT 16440 OWNER TO app;
+GRANT USAGE ON SCHEMA public TO PUBLIC;
+GRANT SELECT ON TABLE public.accounts TO ro;
+ALTER DEFAULT PRIVILEGES FOR ROLE app IN SCHEMA public GRANT SELECT ON TABLES TO ro;
+`
+
+	d, err := acl.ReadDump(strings.NewReader(dump))
+	if err != nil {
+		t.Fatalf("unable to read dump: %v", err)
+	}
+
+	db := snapshotDB().Open()
+	defer db.Close()
+
+	want, err := snapshot.Capture(context.Background(), db)
+	if err != nil {
+		t.Fatalf("unable to capture snapshot: %v", err)
+	}
+
+	got := snapshot.FromDump(d)
+	if !reflect.DeepEqual(got.Objects, want.Objects) {
+		t.Fatalf("bad: expected %+v to equal %+v", got.Objects, want.Objects)
+	}
+
+	if !reflect.DeepEqual(got.DefaultACLs, want.DefaultACLs) {
+		t.Fatalf("bad: expected %+v to equal %+v", got.DefaultACLs, want.DefaultACLs)
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		err  string
+	}{
+		{
+			name: "empty",
+			in:   `{"version": 1}`,
+		},
+		{
+			name: "missing version",
+			in:   `{"objects": []}`,
+			err:  "unsupported snapshot version 0",
+		},
+		{
+			name: "future version",
+			in:   `{"version": 2}`,
+			err:  "unsupported snapshot version 2",
+		},
+		{
+			name: "bad aclitem",
+			in:   `{"version": 1, "objects": [{"kind": "table", "name": "t", "acl": ["bar*"]}]}`,
+			err:  "invalid aclStr format",
+		},
+		{
+			name: "bad privilege for kind",
+			in:   `{"version": 1, "objects": [{"kind": "schema", "name": "s", "acl": ["foo=r/bar"]}]}`,
+			err:  "invalid flags set for schema",
+		},
+		{
+			name: "bad default privilege for kind",
+			in:   `{"version": 1, "default_acls": [{"role": "app", "kind": "function", "acl": ["foo=U/app"]}]}`,
+			err:  "invalid flags set for function",
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			_, err := snapshot.Read(strings.NewReader(test.in))
+			if err != nil && test.err == "" {
+				t.Fatalf("unable to read snapshot: %v", err)
+			}
+
+			if test.err == "" {
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected failure")
+			}
+
+			if !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("bad: expected %q to contain %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package acl
+
+import (
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Action is the verb of a Statement.
+type Action string
+
+// The actions a Statement can take.
+const (
+	Grant  Action = "GRANT"
+	Revoke Action = "REVOKE"
+)
+
+// Statement is a GRANT or REVOKE of privileges on one object, or, when
+// Default or DefaultFor is set, the equivalent ALTER DEFAULT PRIVILEGES
+// statement.
+type Statement struct {
+	Action     Action
+	Privileges Privileges
+
+	// WithGrantOption adds WITH GRANT OPTION to a GRANT and turns a REVOKE
+	// into REVOKE GRANT OPTION FOR.
+	WithGrantOption bool
+
+	// Objects are the targets of the statement, all of the same kind.  Their
+	// ACLs are ignored.  Column objects must belong to the same relation.
+	// With AllInSchema or Default only their Kind and Schema are used.
+	Objects []Object
+
+	// AllInSchema makes the statement apply to every object of its kind in
+	// the schemas of Objects, e.g. GRANT ... ON ALL TABLES IN SCHEMA.
+	AllInSchema bool
+
+	// Grantees are the roles the statement applies to.  The empty role is
+	// PUBLIC.
+	Grantees []string
+
+	// Cascade adds CASCADE to a REVOKE.
+	Cascade bool
+
+	// GrantedBy adds a GRANTED BY clause when not empty.
+	GrantedBy string
+
+	// Default makes the statement an ALTER DEFAULT PRIVILEGES statement.
+	// It is implied by DefaultFor.
+	Default bool
+
+	// DefaultFor is the role whose future objects an ALTER DEFAULT
+	// PRIVILEGES statement applies to.  When it is empty the statement has no
+	// FOR ROLE clause and applies to the current role.
+	DefaultFor string
+
+	// Roles, when not empty, makes the statement a role membership grant or
+	// revoke, e.g. GRANT "app_ro" TO "alice".  WithGrantOption is rendered
+	// as the admin option and Privileges and Objects are ignored.
+	Roles []string
+}
+
+// Statements is a list of statements in execution order.
+type Statements []Statement
+
+// SQL returns the statement as SQL text without a trailing semicolon.
+func (s Statement) SQL() string {
+	if len(s.Roles) > 0 {
+		return s.membershipSQL()
+	}
+
+	b := new(strings.Builder)
+	if s.isDefault() {
+		b.WriteString("ALTER DEFAULT PRIVILEGES")
+		if s.DefaultFor != "" {
+			b.WriteString(" FOR ROLE " + pq.QuoteIdentifier(s.DefaultFor))
+		}
+		if schemas := s.schemas(); len(schemas) > 0 {
+			b.WriteString(" IN SCHEMA " + schemas)
+		}
+		b.WriteString(" ")
+	}
+
+	b.WriteString(string(s.Action))
+	if s.Action == Revoke && s.WithGrantOption {
+		b.WriteString(" GRANT OPTION FOR")
+	}
+
+	b.WriteString(" " + s.privileges() + " ON " + s.target())
+
+	if s.Action == Revoke {
+		b.WriteString(" FROM ")
+	} else {
+		b.WriteString(" TO ")
+	}
+
+	s.writeGrantees(b)
+
+	if s.Action == Grant && s.WithGrantOption {
+		b.WriteString(" WITH GRANT OPTION")
+	}
+
+	s.writeTail(b)
+	return b.String()
+}
+
+// membershipSQL renders a role membership statement.
+func (s Statement) membershipSQL() string {
+	b := new(strings.Builder)
+	b.WriteString(string(s.Action))
+	if s.Action == Revoke && s.WithGrantOption {
+		b.WriteString(" ADMIN OPTION FOR")
+	}
+
+	quoted := make([]string, 0, len(s.Roles))
+	for _, role := range s.Roles {
+		quoted = append(quoted, pq.QuoteIdentifier(role))
+	}
+	b.WriteString(" " + strings.Join(quoted, ", "))
+
+	if s.Action == Revoke {
+		b.WriteString(" FROM ")
+	} else {
+		b.WriteString(" TO ")
+	}
+	s.writeGrantees(b)
+
+	if s.Action == Grant && s.WithGrantOption {
+		b.WriteString(" WITH ADMIN OPTION")
+	}
+
+	s.writeTail(b)
+	return b.String()
+}
+
+func (s Statement) writeGrantees(b *strings.Builder) {
+	for i, role := range s.Grantees {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(quoteRole(role))
+	}
+}
+
+// writeTail writes the GRANTED BY and CASCADE clauses.
+func (s Statement) writeTail(b *strings.Builder) {
+	if s.GrantedBy != "" {
+		b.WriteString(" GRANTED BY " + pq.QuoteIdentifier(s.GrantedBy))
+	}
+
+	if s.Action == Revoke && s.Cascade {
+		b.WriteString(" CASCADE")
+	}
+}
+
+// String returns the statement's SQL.
+func (s Statement) String() string {
+	return s.SQL()
+}
+
+// SQL returns the SQL text of every statement.
+func (s Statements) SQL() []string {
+	queries := make([]string, 0, len(s))
+	for _, stmt := range s {
+		queries = append(queries, stmt.SQL())
+	}
+
+	return queries
+}
+
+// isDefault returns true for ALTER DEFAULT PRIVILEGES statements.
+func (s Statement) isDefault() bool {
+	return s.Default || s.DefaultFor != ""
+}
+
+// kind returns the kind of the statement's objects.
+func (s Statement) kind() ObjectKind {
+	if len(s.Objects) == 0 {
+		return ""
+	}
+
+	return s.Objects[0].Kind
+}
+
+// privileges returns the privilege list of the statement, adding the column
+// list for column privileges.
+func (s Statement) privileges() string {
+	var columns string
+	if s.kind() == KindColumn && !s.isDefault() {
+		quoted := make([]string, 0, len(s.Objects))
+		for _, o := range s.Objects {
+			quoted = append(quoted, pq.QuoteIdentifier(o.Column))
+		}
+		columns = " (" + strings.Join(quoted, ", ") + ")"
+	}
+
+	names := privilegeList(s.Privileges)
+	for i := range names {
+		names[i] += columns
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// allInSchemaKeywords maps the object kinds that ALL ... IN SCHEMA can grant
+// on to their plural keyword.  ALL FUNCTIONS does not cover procedures, but
+// ALL ROUTINES covers both.
+var allInSchemaKeywords = map[ObjectKind]string{
+	KindFunction:  "FUNCTIONS",
+	KindProcedure: "PROCEDURES",
+	KindRoutine:   "ROUTINES",
+	KindSequence:  "SEQUENCES",
+	KindTable:     "TABLES",
+}
+
+// target returns the ON clause of the statement.
+func (s Statement) target() string {
+	kind := s.kind()
+	switch {
+	case s.isDefault():
+		return defaultACLKeywords[kind]
+	case s.AllInSchema:
+		return "ALL " + allInSchemaKeywords[kind] + " IN SCHEMA " + s.schemas()
+	case kind == KindColumn:
+		return s.Objects[0].target()
+	}
+
+	identities := make([]string, 0, len(s.Objects))
+	for _, o := range s.Objects {
+		identities = append(identities, o.identity())
+	}
+
+	return objectKinds[kind].keyword + " " + strings.Join(identities, ", ")
+}
+
+// schemas returns the distinct, quoted schemas of the statement's objects.
+func (s Statement) schemas() string {
+	var quoted []string
+	seen := make(map[string]bool)
+	for _, o := range s.Objects {
+		if o.Schema != "" && !seen[o.Schema] {
+			seen[o.Schema] = true
+			quoted = append(quoted, pq.QuoteIdentifier(o.Schema))
+		}
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// statements returns one statement per privilege and grantee in acls, in the
+// order of privilegeNames.  With the Revoke action, privileges held with the
+// grant option only have the grant option revoked.
+func statements(action Action, target Object, defaultFor string, acls []ACL) Statements {
+	target.ACL = nil
+
+	stmts := make(Statements, 0, len(acls))
+	for _, acl := range acls {
+		for _, p := range privilegeNames {
+			if !acl.GetPrivilege(p.priv) {
+				continue
+			}
+
+			stmts = append(stmts, Statement{
+				Action:          action,
+				Privileges:      p.priv,
+				WithGrantOption: acl.GetGrantOption(p.priv),
+				Objects:         []Object{target},
+				Grantees:        []string{acl.Role},
+				DefaultFor:      defaultFor,
+			})
+		}
+	}
+
+	return stmts
+}
@@ -0,0 +1,763 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// targetKeywords maps the object type keywords of GRANT and REVOKE to object
+// kinds.  Longer keyword sequences are listed first.
+var targetKeywords = []struct {
+	words []string
+	kind  ObjectKind
+}{
+	{[]string{"foreign", "data", "wrapper"}, KindForeignDataWrapper},
+	{[]string{"foreign", "server"}, KindForeignServer},
+	{[]string{"large", "object"}, KindLargeObject},
+	{[]string{"database"}, KindDatabase},
+	{[]string{"domain"}, KindDomain},
+	{[]string{"function"}, KindFunction},
+	{[]string{"procedure"}, KindProcedure},
+	{[]string{"routine"}, KindRoutine},
+	{[]string{"language"}, KindLanguage},
+	{[]string{"schema"}, KindSchema},
+	{[]string{"sequence"}, KindSequence},
+	{[]string{"table"}, KindTable},
+	{[]string{"tablespace"}, KindTablespace},
+	{[]string{"type"}, KindType},
+}
+
+// pluralKeywords maps the plural object type keywords of ALL ... IN SCHEMA
+// and ALTER DEFAULT PRIVILEGES to object kinds.
+var pluralKeywords = []struct {
+	words []string
+	kind  ObjectKind
+}{
+	{[]string{"large", "objects"}, KindLargeObject},
+	{[]string{"functions"}, KindFunction},
+	{[]string{"procedures"}, KindProcedure},
+	{[]string{"routines"}, KindRoutine},
+	{[]string{"schemas"}, KindSchema},
+	{[]string{"sequences"}, KindSequence},
+	{[]string{"tables"}, KindTable},
+	{[]string{"types"}, KindType},
+}
+
+// ParseStatement parses a GRANT, REVOKE or ALTER DEFAULT PRIVILEGES
+// statement, such as one returned by Statement.SQL, and validates its
+// privileges against the object kind.
+//
+// A statement is returned as several Statements when it cannot be expressed
+// as one: column privileges with different column lists, column privileges
+// on several tables, or default privileges for several roles.  ALTER DEFAULT
+// PRIVILEGES without FOR ROLE is returned with Default set and an empty
+// DefaultFor, as it applies to the current role.  Grantees must be named
+// roles or PUBLIC rather than CURRENT_USER and the like.
+func ParseStatement(sql string) (Statements, error) {
+	toks, err := lex(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	if n := len(toks); n > 0 && toks[n-1].kind == tokPunct && toks[n-1].text == ";" {
+		toks = toks[:n-1]
+	}
+
+	return parseTokens(toks)
+}
+
+// ScriptError is a problem with one statement of a SQL script.
+type ScriptError struct {
+	Line int
+	Err  error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// ParseScript parses every GRANT, REVOKE and ALTER DEFAULT PRIVILEGES
+// statement in a SQL script, such as a migration file, and skips all other
+// statements and psql meta-commands.  Statements that cannot be parsed are
+// reported as *ScriptError values joined with errors.Join, alongside the
+// statements that could.
+func ParseScript(script string) (Statements, error) {
+	toks, err := lex(script)
+	if err != nil {
+		return nil, err
+	}
+
+	var stmts Statements
+	var errs []error
+	for _, stmt := range splitStatements(toks) {
+		if !isACLStatement(stmt) {
+			continue
+		}
+
+		parsed, err := parseTokens(stmt)
+		if err != nil {
+			errs = append(errs, &ScriptError{Line: stmt[0].line, Err: err})
+			continue
+		}
+
+		stmts = append(stmts, parsed...)
+	}
+
+	return stmts, errors.Join(errs...)
+}
+
+// splitStatements splits toks into statements at top-level semicolons.  psql
+// meta-commands such as \connect, which end at the end of their line, are
+// dropped.
+func splitStatements(toks []token) [][]token {
+	var stmts [][]token
+	for len(toks) > 0 {
+		if t := toks[0]; t.kind == tokPunct && t.text == `\` {
+			n := 1
+			for n < len(toks) && toks[n].line == t.line {
+				n++
+			}
+			toks = toks[n:]
+			continue
+		}
+
+		n := 0
+		for n < len(toks) && !(toks[n].kind == tokPunct && toks[n].text == ";") {
+			n++
+		}
+
+		if n > 0 {
+			stmts = append(stmts, toks[:n])
+		}
+		if n < len(toks) {
+			n++
+		}
+		toks = toks[n:]
+	}
+
+	return stmts
+}
+
+// isACLStatement reports whether toks start a statement ParseStatement
+// understands.
+func isACLStatement(toks []token) bool {
+	p := &stmtParser{toks: toks}
+	return p.peekWords("grant") || p.peekWords("revoke") || p.peekWords("alter", "default", "privileges")
+}
+
+func parseTokens(toks []token) (Statements, error) {
+	p := &stmtParser{toks: toks}
+
+	var stmts Statements
+	var err error
+	switch {
+	case p.words("alter", "default", "privileges"):
+		stmts, err = p.defaults()
+	case p.words("grant"):
+		stmts, err = p.grantOrRevoke(Grant)
+	case p.words("revoke"):
+		stmts, err = p.grantOrRevoke(Revoke)
+	default:
+		return nil, p.errorf("GRANT, REVOKE or ALTER DEFAULT PRIVILEGES")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != tokEOF {
+		return nil, p.errorf("end of statement")
+	}
+
+	return stmts, nil
+}
+
+// stmtParser is a recursive descent parser over the tokens of one statement.
+type stmtParser struct {
+	toks []token
+	pos  int
+}
+
+func (p *stmtParser) peek() token {
+	return p.peekAt(0)
+}
+
+func (p *stmtParser) peekAt(i int) token {
+	if p.pos+i >= len(p.toks) {
+		return token{kind: tokEOF}
+	}
+
+	return p.toks[p.pos+i]
+}
+
+func (p *stmtParser) next() token {
+	t := p.peek()
+	if t.kind != tokEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+// peekWords reports whether the next tokens are the given unquoted words.
+func (p *stmtParser) peekWords(words ...string) bool {
+	for i, w := range words {
+		if t := p.peekAt(i); t.kind != tokWord || t.value != w {
+			return false
+		}
+	}
+
+	return true
+}
+
+// words consumes the given unquoted words if they are next.
+func (p *stmtParser) words(words ...string) bool {
+	if !p.peekWords(words...) {
+		return false
+	}
+	p.pos += len(words)
+
+	return true
+}
+
+func (p *stmtParser) expect(words ...string) error {
+	if !p.words(words...) {
+		return p.errorf(strings.ToUpper(strings.Join(words, " ")))
+	}
+
+	return nil
+}
+
+func (p *stmtParser) punct(s string) bool {
+	if t := p.peek(); t.kind == tokPunct && t.text == s {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *stmtParser) errorf(expected string) error {
+	t := p.peek()
+	if t.kind == tokEOF {
+		return fmt.Errorf("syntax error at end of statement: expected %s", expected)
+	}
+
+	return fmt.Errorf("syntax error at line %d, column %d near %s: expected %s", t.line, t.col, t, expected)
+}
+
+// ident parses a quoted or unquoted identifier.
+func (p *stmtParser) ident() (string, error) {
+	switch t := p.peek(); t.kind {
+	case tokWord, tokQuoted:
+		p.pos++
+		return t.value, nil
+	}
+
+	return "", p.errorf("an identifier")
+}
+
+// identList parses a comma-separated list of identifiers.
+func (p *stmtParser) identList() ([]string, error) {
+	var names []string
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+
+		if !p.punct(",") {
+			return names, nil
+		}
+	}
+}
+
+// grantees parses the role list after TO or FROM.
+func (p *stmtParser) grantees() ([]string, error) {
+	var roles []string
+	for {
+		p.words("group")
+
+		t := p.peek()
+		switch {
+		case t.kind == tokWord && t.value == "public":
+			p.pos++
+			roles = append(roles, "")
+		case t.kind == tokWord && (t.value == "current_user" || t.value == "current_role" || t.value == "session_user"):
+			return nil, fmt.Errorf("grantee %s is not supported, name the role instead", strings.ToUpper(t.value))
+		default:
+			role, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			roles = append(roles, role)
+		}
+
+		if !p.punct(",") {
+			return roles, nil
+		}
+	}
+}
+
+// privilege is one entry of a privilege list with its optional columns.
+type privilege struct {
+	priv    Privileges
+	all     bool
+	columns []string
+}
+
+// privileges parses a privilege list, stopping before ON.
+func (p *stmtParser) privileges() ([]privilege, error) {
+	var privs []privilege
+	for {
+		var pr privilege
+		if p.words("all") {
+			p.words("privileges")
+			pr.all = true
+		} else {
+			t := p.next()
+			if t.kind != tokWord {
+				p.pos--
+				return nil, p.errorf("a privilege")
+			}
+
+			priv, err := ParsePrivilege(t.value)
+			if err != nil {
+				return nil, err
+			}
+			pr.priv = priv
+		}
+
+		if p.punct("(") {
+			cols, err := p.identList()
+			if err != nil {
+				return nil, err
+			}
+			if !p.punct(")") {
+				return nil, p.errorf(")")
+			}
+			pr.columns = cols
+		}
+
+		privs = append(privs, pr)
+		if !p.punct(",") {
+			return privs, nil
+		}
+	}
+}
+
+// hasOn reports whether an ON keyword follows before TO or FROM, which
+// distinguishes privilege grants from role membership grants.
+func (p *stmtParser) hasOn() bool {
+	for _, t := range p.toks[p.pos:] {
+		if t.kind != tokWord {
+			continue
+		}
+
+		switch t.value {
+		case "on":
+			return true
+		case "to", "from":
+			return false
+		}
+	}
+
+	return false
+}
+
+func (p *stmtParser) grantOrRevoke(action Action) (Statements, error) {
+	if !p.hasOn() {
+		return p.membership(action)
+	}
+
+	var grantOption bool
+	if action == Revoke && p.words("grant", "option", "for") {
+		grantOption = true
+	}
+
+	privs, err := p.privileges()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect("on"); err != nil {
+		return nil, err
+	}
+
+	kind, objs, all, err := p.target()
+	if err != nil {
+		return nil, err
+	}
+
+	base := Statement{Action: action, WithGrantOption: grantOption, AllInSchema: all}
+	if err := p.tail(&base); err != nil {
+		return nil, err
+	}
+
+	return privilegeStatements(base, kind, objs, privs)
+}
+
+// target parses the object type and names after ON.
+func (p *stmtParser) target() (ObjectKind, []Object, bool, error) {
+	if p.words("all") {
+		kind, ok := p.plural()
+		if _, valid := allInSchemaKeywords[kind]; !ok || !valid {
+			return "", nil, false, p.errorf("TABLES, SEQUENCES, FUNCTIONS, PROCEDURES or ROUTINES")
+		}
+
+		if err := p.expect("in", "schema"); err != nil {
+			return "", nil, false, err
+		}
+
+		schemas, err := p.identList()
+		if err != nil {
+			return "", nil, false, err
+		}
+
+		objs := make([]Object, 0, len(schemas))
+		for _, s := range schemas {
+			objs = append(objs, Object{Kind: kind, Schema: s})
+		}
+
+		return kind, objs, true, nil
+	}
+
+	if p.peekWords("parameter") {
+		return "", nil, false, fmt.Errorf("privileges on parameters are not supported")
+	}
+
+	kind := KindTable
+	for _, k := range targetKeywords {
+		if p.words(k.words...) {
+			kind = k.kind
+			break
+		}
+	}
+
+	var objs []Object
+	for {
+		obj, err := p.object(kind)
+		if err != nil {
+			return "", nil, false, err
+		}
+		objs = append(objs, obj)
+
+		if !p.punct(",") {
+			return kind, objs, false, nil
+		}
+	}
+}
+
+// plural parses a plural object type keyword.
+func (p *stmtParser) plural() (ObjectKind, bool) {
+	for _, k := range pluralKeywords {
+		if p.words(k.words...) {
+			return k.kind, true
+		}
+	}
+
+	return "", false
+}
+
+// object parses one object name of the given kind.
+func (p *stmtParser) object(kind ObjectKind) (Object, error) {
+	obj := Object{Kind: kind}
+	if kind == KindLargeObject {
+		t := p.next()
+		oid, err := strconv.ParseUint(t.value, 10, 32)
+		if t.kind != tokNumber || err != nil {
+			p.pos--
+			return Object{}, p.errorf("a large object OID")
+		}
+		obj.OID = uint32(oid)
+
+		return obj, nil
+	}
+
+	name, err := p.ident()
+	if err != nil {
+		return Object{}, err
+	}
+	obj.Name = name
+
+	if p.punct(".") {
+		if obj.Name, err = p.ident(); err != nil {
+			return Object{}, err
+		}
+		obj.Schema = name
+	}
+
+	if kind.routine() && p.punct("(") {
+		if obj.Signature, err = p.signature(); err != nil {
+			return Object{}, err
+		}
+	}
+
+	return obj, nil
+}
+
+// signature returns the argument list of a function up to its closing
+// parenthesis, normalized to single spaces after commas and between words.
+func (p *stmtParser) signature() (string, error) {
+	var b strings.Builder
+	depth := 0
+	prevWord := false
+	for {
+		t := p.next()
+		switch {
+		case t.kind == tokEOF:
+			return "", p.errorf(")")
+		case t.kind == tokPunct && t.text == ")" && depth == 0:
+			return b.String(), nil
+		case t.kind == tokPunct && t.text == "(":
+			depth++
+		case t.kind == tokPunct && t.text == ")":
+			depth--
+		}
+
+		isWord := t.kind != tokPunct
+		if isWord && prevWord {
+			b.WriteByte(' ')
+		}
+		b.WriteString(t.text)
+		if t.kind == tokPunct && t.text == "," && depth == 0 {
+			b.WriteByte(' ')
+		}
+		prevWord = isWord
+	}
+}
+
+// tail parses the grantees and the trailing options of a privilege
+// statement.
+func (p *stmtParser) tail(s *Statement) error {
+	return p.tailOptions(s, "grant")
+}
+
+// tailOptions parses the grantees and the trailing options of a statement,
+// where option is the keyword before OPTION: GRANT or ADMIN.
+func (p *stmtParser) tailOptions(s *Statement, option string) error {
+	dir := "to"
+	if s.Action == Revoke {
+		dir = "from"
+	}
+
+	if err := p.expect(dir); err != nil {
+		return err
+	}
+
+	grantees, err := p.grantees()
+	if err != nil {
+		return err
+	}
+	s.Grantees = grantees
+
+	if s.Action == Grant && p.words("with") {
+		switch {
+		case p.words(option, "option"), p.words(option, "true"):
+			s.WithGrantOption = true
+		case p.words(option, "false"):
+		default:
+			return p.errorf(strings.ToUpper(option) + " OPTION")
+		}
+	}
+
+	if p.words("granted", "by") {
+		if s.GrantedBy, err = p.ident(); err != nil {
+			return err
+		}
+	}
+
+	if s.Action == Revoke {
+		switch {
+		case p.words("cascade"):
+			s.Cascade = true
+		case p.words("restrict"):
+		}
+	}
+
+	return nil
+}
+
+func (p *stmtParser) membership(action Action) (Statements, error) {
+	s := Statement{Action: action}
+	if action == Revoke && p.words("admin", "option", "for") {
+		s.WithGrantOption = true
+	}
+
+	roles, err := p.identList()
+	if err != nil {
+		return nil, err
+	}
+	s.Roles = roles
+
+	if err := p.tailOptions(&s, "admin"); err != nil {
+		return nil, err
+	}
+
+	return Statements{s}, nil
+}
+
+func (p *stmtParser) defaults() (Statements, error) {
+	var roles, schemas []string
+	for more := true; more; {
+		var err error
+		switch {
+		case p.words("for", "role"), p.words("for", "user"):
+			roles, err = p.identList()
+		case p.words("in", "schema"):
+			schemas, err = p.identList()
+		default:
+			more = false
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var base Statement
+	switch {
+	case p.words("grant"):
+		base.Action = Grant
+	case p.words("revoke"):
+		base.Action = Revoke
+		base.WithGrantOption = p.words("grant", "option", "for")
+	default:
+		return nil, p.errorf("GRANT or REVOKE")
+	}
+
+	privs, err := p.privileges()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect("on"); err != nil {
+		return nil, err
+	}
+
+	kind, ok := p.plural()
+	if !ok {
+		return nil, p.errorf("TABLES, SEQUENCES, FUNCTIONS, ROUTINES, TYPES, SCHEMAS or LARGE OBJECTS")
+	}
+
+	if err := p.tail(&base); err != nil {
+		return nil, err
+	}
+
+	var mask Privileges
+	for _, pr := range privs {
+		if len(pr.columns) > 0 {
+			return nil, fmt.Errorf("column privileges cannot be set as default privileges")
+		}
+
+		if pr.all {
+			pr.priv = kind.ValidPrivileges()
+		}
+		mask |= pr.priv
+	}
+	base.Privileges = mask
+
+	objs := []Object{{Kind: kind}}
+	if len(schemas) > 0 {
+		objs = objs[:0]
+		for _, s := range schemas {
+			objs = append(objs, Object{Kind: kind, Schema: s})
+		}
+	}
+	base.Objects = objs
+
+	base.Default = true
+	if len(roles) == 0 {
+		roles = []string{""}
+	}
+
+	stmts := make(Statements, 0, len(roles))
+	for _, role := range roles {
+		for _, o := range objs {
+			def := DefaultACL{Role: role, Schema: o.Schema, Kind: kind, ACL: []ACL{{Privileges: mask}}}
+			if role == "" {
+				// Name the current role in error messages.
+				def.Role = "CURRENT_ROLE"
+			}
+			if err := def.Validate(); err != nil {
+				return nil, err
+			}
+		}
+
+		s := base
+		s.DefaultFor = role
+		stmts = append(stmts, s)
+	}
+
+	return stmts, nil
+}
+
+// privilegeStatements builds the statements for a privilege list on objs:
+// one for the object-level privileges and one per column list and table for
+// column privileges.
+func privilegeStatements(base Statement, kind ObjectKind, objs []Object, privs []privilege) (Statements, error) {
+	var tableMask Privileges
+	var columnLists [][]string
+	columnMasks := make(map[string]Privileges)
+
+	for _, pr := range privs {
+		valid := kind.ValidPrivileges()
+		if len(pr.columns) > 0 {
+			if kind != KindTable || base.AllInSchema {
+				return nil, fmt.Errorf("column privileges are only valid on tables")
+			}
+			valid = KindColumn.ValidPrivileges()
+		}
+
+		if pr.all {
+			pr.priv = valid
+		}
+
+		if pr.priv&^valid != 0 {
+			k := kind
+			if len(pr.columns) > 0 {
+				k = KindColumn
+			}
+			return nil, fmt.Errorf("privilege %s is not valid for %s, only %s allowed", pr.priv, k, valid)
+		}
+
+		if len(pr.columns) == 0 {
+			tableMask |= pr.priv
+			continue
+		}
+
+		key := strings.Join(pr.columns, "\x00")
+		if _, ok := columnMasks[key]; !ok {
+			columnLists = append(columnLists, pr.columns)
+		}
+		columnMasks[key] |= pr.priv
+	}
+
+	var stmts Statements
+	if tableMask != NoPrivs {
+		s := base
+		s.Privileges = tableMask
+		s.Objects = objs
+		stmts = append(stmts, s)
+	}
+
+	for _, cols := range columnLists {
+		for _, obj := range objs {
+			s := base
+			s.Privileges = columnMasks[strings.Join(cols, "\x00")]
+			for _, c := range cols {
+				s.Objects = append(s.Objects, Object{Kind: KindColumn, Schema: obj.Schema, Name: obj.Name, Column: c})
+			}
+			stmts = append(stmts, s)
+		}
+	}
+
+	return stmts, nil
+}
@@ -0,0 +1,271 @@
+package acl_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+func TestParseStatement(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+		fail bool
+	}{
+		{
+			name: "grant",
+			sql:  `grant select on accounts to ro;`,
+			want: []string{`GRANT SELECT ON TABLE "accounts" TO "ro"`},
+		},
+		{
+			name: "quoted and qualified names",
+			sql:  `GRANT INSERT, SELECT ON TABLE "Public"."Accounts", public.ledger TO "RW", Public WITH GRANT OPTION GRANTED BY app`,
+			want: []string{`GRANT INSERT, SELECT ON TABLE "Public"."Accounts", "public"."ledger" TO "RW", PUBLIC WITH GRANT OPTION GRANTED BY "app"`},
+		},
+		{
+			name: "all privileges",
+			sql:  `GRANT ALL PRIVILEGES ON SEQUENCE s TO GROUP app`,
+			want: []string{`GRANT SELECT, UPDATE, USAGE ON SEQUENCE "s" TO "app"`},
+		},
+		{
+			name: "revoke grant option",
+			sql:  `REVOKE GRANT OPTION FOR USAGE ON SCHEMA app FROM ro CASCADE`,
+			want: []string{`REVOKE GRANT OPTION FOR USAGE ON SCHEMA "app" FROM "ro" CASCADE`},
+		},
+		{
+			name: "column privileges",
+			sql:  `GRANT SELECT (id, email), UPDATE (email), INSERT ON users, accounts TO rw`,
+			want: []string{
+				`GRANT INSERT ON TABLE "users", "accounts" TO "rw"`,
+				`GRANT SELECT ("id", "email") ON TABLE "users" TO "rw"`,
+				`GRANT SELECT ("id", "email") ON TABLE "accounts" TO "rw"`,
+				`GRANT UPDATE ("email") ON TABLE "users" TO "rw"`,
+				`GRANT UPDATE ("email") ON TABLE "accounts" TO "rw"`,
+			},
+		},
+		{
+			name: "function",
+			sql:  `GRANT EXECUTE ON FUNCTION app.f(integer,  text , numeric(10,2)) TO ro`,
+			want: []string{`GRANT EXECUTE ON FUNCTION "app"."f"(integer, text, numeric(10,2)) TO "ro"`},
+		},
+		{
+			name: "procedure",
+			sql:  `GRANT EXECUTE ON PROCEDURE p() TO ro`,
+			want: []string{`GRANT EXECUTE ON PROCEDURE "p"() TO "ro"`},
+		},
+		{
+			name: "large object",
+			sql:  `GRANT SELECT ON LARGE OBJECT 1234 TO ro`,
+			want: []string{`GRANT SELECT ON LARGE OBJECT 1234 TO "ro"`},
+		},
+		{
+			name: "foreign data wrapper",
+			sql:  `GRANT USAGE ON FOREIGN DATA WRAPPER postgres_fdw TO ro`,
+			want: []string{`GRANT USAGE ON FOREIGN DATA WRAPPER "postgres_fdw" TO "ro"`},
+		},
+		{
+			name: "all in schema",
+			sql:  `GRANT SELECT ON ALL TABLES IN SCHEMA app, audit TO ro`,
+			want: []string{`GRANT SELECT ON ALL TABLES IN SCHEMA "app", "audit" TO "ro"`},
+		},
+		{
+			name: "routine",
+			sql:  `GRANT EXECUTE ON ROUTINE app.archive(date) TO ops`,
+			want: []string{`GRANT EXECUTE ON ROUTINE "app"."archive"(date) TO "ops"`},
+		},
+		{
+			name: "all routines in schema",
+			sql:  `REVOKE EXECUTE ON ALL ROUTINES IN SCHEMA app FROM PUBLIC`,
+			want: []string{`REVOKE EXECUTE ON ALL ROUTINES IN SCHEMA "app" FROM PUBLIC`},
+		},
+		{
+			name: "default privileges without role",
+			sql:  `ALTER DEFAULT PRIVILEGES IN SCHEMA app GRANT SELECT ON TABLES TO ro`,
+			want: []string{`ALTER DEFAULT PRIVILEGES IN SCHEMA "app" GRANT SELECT ON TABLES TO "ro"`},
+		},
+		{
+			name: "default privileges on routines",
+			sql:  `ALTER DEFAULT PRIVILEGES REVOKE EXECUTE ON ROUTINES FROM PUBLIC`,
+			want: []string{`ALTER DEFAULT PRIVILEGES REVOKE EXECUTE ON ROUTINES FROM PUBLIC`},
+		},
+		{
+			name: "all procedures in schema",
+			sql:  `GRANT EXECUTE ON ALL PROCEDURES IN SCHEMA app TO ops`,
+			want: []string{`GRANT EXECUTE ON ALL PROCEDURES IN SCHEMA "app" TO "ops"`},
+		},
+		{
+			name: "membership",
+			sql:  `GRANT app_ro, app_rw TO alice WITH ADMIN OPTION`,
+			want: []string{`GRANT "app_ro", "app_rw" TO "alice" WITH ADMIN OPTION`},
+		},
+		{
+			name: "revoke membership",
+			sql:  `REVOKE ADMIN OPTION FOR app_ro FROM alice`,
+			want: []string{`REVOKE ADMIN OPTION FOR "app_ro" FROM "alice"`},
+		},
+		{
+			name: "default privileges",
+			sql:  `ALTER DEFAULT PRIVILEGES IN SCHEMA app FOR ROLE owner, migrator GRANT SELECT ON TABLES TO ro`,
+			want: []string{
+				`ALTER DEFAULT PRIVILEGES FOR ROLE "owner" IN SCHEMA "app" GRANT SELECT ON TABLES TO "ro"`,
+				`ALTER DEFAULT PRIVILEGES FOR ROLE "migrator" IN SCHEMA "app" GRANT SELECT ON TABLES TO "ro"`,
+			},
+		},
+		{
+			name: "revoke default privileges",
+			sql:  `ALTER DEFAULT PRIVILEGES FOR USER owner REVOKE ALL ON FUNCTIONS FROM PUBLIC`,
+			want: []string{`ALTER DEFAULT PRIVILEGES FOR ROLE "owner" REVOKE EXECUTE ON FUNCTIONS FROM PUBLIC`},
+		},
+		{
+			name: "invalid privilege",
+			sql:  `GRANT EXECUTE ON TABLE t TO ro`,
+			fail: true,
+		},
+		{
+			name: "invalid column privilege",
+			sql:  `GRANT DELETE (id) ON TABLE t TO ro`,
+			fail: true,
+		},
+		{
+			name: "unknown privilege",
+			sql:  `GRANT FLY ON TABLE t TO ro`,
+			fail: true,
+		},
+		{
+			name: "current user",
+			sql:  `GRANT SELECT ON t TO CURRENT_USER`,
+			fail: true,
+		},
+		{
+			name: "parameter",
+			sql:  `GRANT SET ON PARAMETER work_mem TO ro`,
+			fail: true,
+		},
+		{
+			name: "default privileges on procedures",
+			sql:  `ALTER DEFAULT PRIVILEGES FOR ROLE app GRANT EXECUTE ON PROCEDURES TO ops`,
+			fail: true,
+		},
+		{
+			name: "schema defaults in schema",
+			sql:  `ALTER DEFAULT PRIVILEGES FOR ROLE o IN SCHEMA app GRANT USAGE ON SCHEMAS TO ro`,
+			fail: true,
+		},
+		{
+			name: "trailing tokens",
+			sql:  `GRANT SELECT ON t TO ro RESTRICT`,
+			fail: true,
+		},
+		{
+			name: "other statement",
+			sql:  `SELECT 1`,
+			fail: true,
+		},
+		{
+			name: "unterminated identifier",
+			sql:  `GRANT SELECT ON "t TO ro`,
+			fail: true,
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			stmts, err := acl.ParseStatement(test.sql)
+			if test.fail {
+				if err == nil {
+					t.Fatalf("expected failure")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unable to parse %+q: %v", test.sql, err)
+			}
+
+			if got := stmts.SQL(); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("bad: expected %#v to equal %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseStatementRoundTrip(t *testing.T) {
+	mustParse := func(s string) acl.ACL {
+		a, err := acl.Parse(s)
+		if err != nil {
+			t.Fatalf("unable to parse %+q: %v", s, err)
+		}
+		return a
+	}
+
+	objects := []acl.Object{
+		{Kind: acl.KindTable, Schema: "app", Name: "accounts", ACL: []acl.ACL{mustParse("ro=r*w/owner"), mustParse("=d/owner")}},
+		{Kind: acl.KindColumn, Schema: "app", Name: "users", Column: "e-mail", ACL: []acl.ACL{mustParse("ro=rw/owner")}},
+		{Kind: acl.KindFunction, Schema: "app", Name: "f", Signature: "integer, text", ACL: []acl.ACL{mustParse("ro=X/owner")}},
+		{Kind: acl.KindLargeObject, OID: 42, ACL: []acl.ACL{mustParse("ro=rw/owner")}},
+		{Kind: acl.KindDatabase, Name: "db", ACL: []acl.ACL{mustParse("ro=CT/owner")}},
+	}
+
+	var stmts acl.Statements
+	for _, o := range objects {
+		stmts = append(stmts, o.Grants()...)
+		stmts = append(stmts, o.Revokes()...)
+	}
+	def := acl.DefaultACL{Role: "owner", Schema: "app", Kind: acl.KindSequence, ACL: []acl.ACL{mustParse("ro=rU/owner")}}
+	stmts = append(stmts, def.Grants()...)
+	stmts = append(stmts, def.Revokes()...)
+	stmts = append(stmts, acl.Compact(stmts, nil)...)
+
+	for _, stmt := range stmts {
+		sql := stmt.SQL()
+		got, err := acl.ParseStatement(sql)
+		if err != nil {
+			t.Fatalf("unable to parse %+q: %v", sql, err)
+		}
+
+		if want := (acl.Statements{stmt}); !reflect.DeepEqual(got.SQL(), want.SQL()) {
+			t.Fatalf("bad: expected %#v to equal %#v", got.SQL(), want.SQL())
+		}
+	}
+}
+
+func TestParseScript(t *testing.T) {
+	script := `
+-- migration 0042
+CREATE TABLE accounts (id int); /* GRANT nothing; */
+CREATE FUNCTION f() RETURNS void AS $body$
+BEGIN
+  EXECUTE 'GRANT ALL ON accounts TO evil';
+END
+$body$ LANGUAGE plpgsql;
+GRANT SELECT ON accounts TO ro;
+GRANT FLY ON accounts TO ro;
+INSERT INTO accounts VALUES (1);
+REVOKE ALL ON FUNCTION f() FROM PUBLIC
+`
+
+	stmts, err := acl.ParseScript(script)
+	if err == nil {
+		t.Fatalf("expected failure")
+	}
+
+	var scriptErr *acl.ScriptError
+	if !errors.As(err, &scriptErr) || scriptErr.Line != 10 {
+		t.Fatalf("bad: expected %v to be reported on line 10", err)
+	}
+
+	want := []string{
+		`GRANT SELECT ON TABLE "accounts" TO "ro"`,
+		`REVOKE EXECUTE ON FUNCTION "f"() FROM PUBLIC`,
+	}
+	if got := stmts.SQL(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("bad: expected %#v to equal %#v", got, want)
+	}
+}
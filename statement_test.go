@@ -0,0 +1,125 @@
+package acl_test
+
+import (
+	"reflect"
+	"testing"
+
+	acl "github.com/sean-/postgresql-acl"
+)
+
+func TestStatementSQL(t *testing.T) {
+	table := acl.Object{Kind: acl.KindTable, Schema: "public", Name: "accounts"}
+
+	tests := []struct {
+		name string
+		stmt acl.Statement
+		sql  string
+	}{
+		{
+			name: "grant",
+			stmt: acl.Statement{Action: acl.Grant, Privileges: acl.Select, Objects: []acl.Object{table}, Grantees: []string{"ro"}},
+			sql:  `GRANT SELECT ON TABLE "public"."accounts" TO "ro"`,
+		},
+		{
+			name: "several privileges and grantees",
+			stmt: acl.Statement{
+				Action:          acl.Grant,
+				Privileges:      acl.Update | acl.Select | acl.Insert,
+				WithGrantOption: true,
+				Objects:         []acl.Object{table},
+				Grantees:        []string{"rw", ""},
+				GrantedBy:       "app",
+			},
+			sql: `GRANT INSERT, SELECT, UPDATE ON TABLE "public"."accounts" TO "rw", PUBLIC WITH GRANT OPTION GRANTED BY "app"`,
+		},
+		{
+			name: "revoke grant option cascade",
+			stmt: acl.Statement{
+				Action:          acl.Revoke,
+				Privileges:      acl.Select,
+				WithGrantOption: true,
+				Objects:         []acl.Object{table},
+				Grantees:        []string{"ro"},
+				Cascade:         true,
+			},
+			sql: `REVOKE GRANT OPTION FOR SELECT ON TABLE "public"."accounts" FROM "ro" CASCADE`,
+		},
+		{
+			name: "columns",
+			stmt: acl.Statement{
+				Action:     acl.Grant,
+				Privileges: acl.Select | acl.Update,
+				Objects:    []acl.Object{{Kind: acl.KindColumn, Schema: "public", Name: "users", Column: "email"}},
+				Grantees:   []string{"ro"},
+			},
+			sql: `GRANT SELECT ("email"), UPDATE ("email") ON TABLE "public"."users" TO "ro"`,
+		},
+		{
+			name: "default privileges",
+			stmt: acl.Statement{
+				Action:     acl.Revoke,
+				Privileges: acl.Execute,
+				Objects:    []acl.Object{{Kind: acl.KindFunction, Schema: "app"}},
+				Grantees:   []string{""},
+				DefaultFor: "app_owner",
+			},
+			sql: `ALTER DEFAULT PRIVILEGES FOR ROLE "app_owner" IN SCHEMA "app" REVOKE EXECUTE ON FUNCTIONS FROM PUBLIC`,
+		},
+		{
+			name: "role membership",
+			stmt: acl.Statement{Action: acl.Grant, Roles: []string{"readers", "writers"}, Grantees: []string{"alice"}, WithGrantOption: true},
+			sql:  `GRANT "readers", "writers" TO "alice" WITH ADMIN OPTION`,
+		},
+		{
+			name: "role membership revoke",
+			stmt: acl.Statement{Action: acl.Revoke, Roles: []string{"readers"}, Grantees: []string{"alice"}, WithGrantOption: true, Cascade: true},
+			sql:  `REVOKE ADMIN OPTION FOR "readers" FROM "alice" CASCADE`,
+		},
+	}
+
+	for i, test := range tests {
+		if test.name == "" {
+			t.Fatalf("test %d needs a name", i)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			if sql := test.stmt.SQL(); sql != test.sql {
+				t.Fatalf("want %+q got %+q", test.sql, sql)
+			}
+		})
+	}
+}
+
+func TestObjectStatements(t *testing.T) {
+	obj := acl.Object{
+		Kind:   acl.KindSequence,
+		Schema: "public",
+		Name:   "ids",
+		Owner:  "app",
+		ACL:    []acl.ACL{{Role: "ro", GrantedBy: "app", Privileges: acl.Select | acl.Usage, GrantOptions: acl.Usage}},
+	}
+
+	target := obj
+	target.ACL = nil
+
+	want := acl.Statements{
+		{Action: acl.Grant, Privileges: acl.Select, Objects: []acl.Object{target}, Grantees: []string{"ro"}},
+		{Action: acl.Grant, Privileges: acl.Usage, WithGrantOption: true, Objects: []acl.Object{target}, Grantees: []string{"ro"}},
+	}
+
+	if got := obj.Grants(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("bad: expected %+v to equal %+v", want, got)
+	}
+
+	// Statements can be filtered before rendering.
+	var usage []string
+	for _, stmt := range obj.Revokes() {
+		if stmt.Privileges == acl.Usage {
+			usage = append(usage, stmt.SQL())
+		}
+	}
+
+	if want := []string{`REVOKE GRANT OPTION FOR USAGE ON SEQUENCE "public"."ids" FROM "ro"`}; !reflect.DeepEqual(want, usage) {
+		t.Fatalf("bad: expected %#v to equal %#v", want, usage)
+	}
+}
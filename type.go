@@ -1,7 +1,5 @@
 package acl
 
-import "fmt"
-
 // Type models the privileges of a type aclitem
 type Type struct {
 	ACL
@@ -9,8 +7,8 @@ type Type struct {
 
 // NewType parses an ACL object and returns a Type object.
 func NewType(acl ACL) (Type, error) {
-	if !validRights(acl, validTypePrivs) {
-		return Type{}, fmt.Errorf("invalid flags set for type (%+q), only %+q allowed", permString(acl.Privileges, acl.GrantOptions), validTypePrivs)
+	if err := validRights(acl, validTypePrivs, "type"); err != nil {
+		return Type{}, err
 	}
 
 	return Type{ACL: acl}, nil